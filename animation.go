@@ -0,0 +1,143 @@
+package zelduh
+
+import "time"
+
+// AnimationMode is what a clip does after its last frame
+type AnimationMode int
+
+const (
+	// AnimationLoop starts again from the first frame
+	AnimationLoop AnimationMode = iota
+	// AnimationOnce stops on the last frame
+	AnimationOnce
+	// AnimationPingPong plays the frames backwards, then forwards again
+	AnimationPingPong
+)
+
+// AnimationFrame is one sprite of an animation clip
+type AnimationFrame struct {
+	SpriteID int
+	// Duration is how long the frame is shown; zero means the default frame duration
+	Duration time.Duration
+}
+
+// AnimationClip is a sequence of frames and how to play them
+type AnimationClip struct {
+	Frames []AnimationFrame
+	Mode   AnimationMode
+}
+
+// SpriteIDs returns the sprite of each frame, in order
+func (c AnimationClip) SpriteIDs() []int {
+	ids := make([]int, len(c.Frames))
+	for i, frame := range c.Frames {
+		ids[i] = frame.SpriteID
+	}
+	return ids
+}
+
+// NewAnimationClip returns a clip that shows each sprite for the default frame duration
+func NewAnimationClip(mode AnimationMode, spriteIDs ...int) AnimationClip {
+	return NewTimedAnimationClip(mode, 0, spriteIDs...)
+}
+
+// NewTimedAnimationClip returns a clip that shows each sprite for duration
+func NewTimedAnimationClip(mode AnimationMode, duration time.Duration, spriteIDs ...int) AnimationClip {
+	clip := AnimationClip{
+		Mode: mode,
+	}
+	for _, id := range spriteIDs {
+		clip.Frames = append(clip.Frames, AnimationFrame{
+			SpriteID: id,
+			Duration: duration,
+		})
+	}
+	return clip
+}
+
+// frameDuration returns how long an animation frame is shown when frameRate ticks
+// is the default
+func frameDuration(frameRate int) time.Duration {
+	return time.Duration(float64(frameRate) / TicksPerSecond * float64(time.Second))
+}
+
+// newComponentAnimationData returns the playback state for a clip
+func newComponentAnimationData(clip AnimationClip, frameRate int) *ComponentAnimationData {
+	data := &ComponentAnimationData{
+		Frames:    clip.SpriteIDs(),
+		Durations: make([]time.Duration, len(clip.Frames)),
+		Mode:      clip.Mode,
+	}
+	for i, frame := range clip.Frames {
+		data.Durations[i] = frame.Duration
+		if data.Durations[i] <= 0 {
+			data.Durations[i] = frameDuration(frameRate)
+		}
+	}
+	return data
+}
+
+// Advance moves the animation on by dt seconds
+//
+// OnComplete is called each time the clip reaches its end: after the last
+// frame of a loop, on returning to the first frame of a ping-pong, or when a
+// once clip finishes.
+func (a *ComponentAnimationData) Advance(dt float64) {
+	if a.Done || len(a.Frames) == 0 {
+		return
+	}
+	a.elapsed += time.Duration(dt * float64(time.Second))
+	for !a.Done && a.elapsed >= a.Durations[a.Frame] {
+		a.elapsed -= a.Durations[a.Frame]
+		a.step()
+	}
+}
+
+// Reset rewinds the animation to its first frame
+func (a *ComponentAnimationData) Reset() {
+	a.Frame = 0
+	a.elapsed = 0
+	a.reverse = false
+	a.Done = false
+}
+
+func (a *ComponentAnimationData) step() {
+	last := len(a.Frames) - 1
+	completed := false
+
+	switch a.Mode {
+	case AnimationLoop:
+		if a.Frame < last {
+			a.Frame++
+		} else {
+			a.Frame = 0
+			completed = true
+		}
+	case AnimationOnce:
+		if a.Frame < last {
+			a.Frame++
+		} else {
+			a.Done = true
+			completed = true
+		}
+	case AnimationPingPong:
+		if last == 0 {
+			completed = true
+		} else if a.reverse {
+			a.Frame--
+			if a.Frame == 0 {
+				a.reverse = false
+				completed = true
+			}
+		} else {
+			a.Frame++
+			if a.Frame == last {
+				a.reverse = true
+			}
+		}
+	}
+
+	if completed && a.OnComplete != nil {
+		a.OnComplete()
+	}
+}
@@ -0,0 +1,137 @@
+package zelduh
+
+import (
+	"testing"
+	"time"
+)
+
+func TestComponentAnimationDataAdvance(t *testing.T) {
+	ms := time.Millisecond
+	clip := func(mode AnimationMode, durations ...time.Duration) AnimationClip {
+		c := AnimationClip{Mode: mode}
+		for i, d := range durations {
+			c.Frames = append(c.Frames, AnimationFrame{SpriteID: i + 1, Duration: d})
+		}
+		return c
+	}
+
+	tests := []struct {
+		name string
+		clip AnimationClip
+		// Each call to Advance, and the frame, completions and Done after it
+		dts         []float64
+		frames      []int
+		completions []int
+		done        []bool
+	}{
+		{
+			"loop",
+			clip(AnimationLoop, 100*ms, 100*ms, 100*ms),
+			[]float64{0.05, 0.05, 0.1, 0.1, 0.1},
+			[]int{0, 1, 2, 0, 1},
+			[]int{0, 0, 0, 1, 1},
+			[]bool{false, false, false, false, false},
+		},
+		{
+			"per-frame durations",
+			clip(AnimationLoop, 100*ms, 300*ms),
+			[]float64{0.1, 0.2, 0.1},
+			[]int{1, 1, 0},
+			[]int{0, 0, 1},
+			[]bool{false, false, false},
+		},
+		{
+			"once stops on its last frame",
+			clip(AnimationOnce, 100*ms, 100*ms, 100*ms),
+			[]float64{0.1, 0.1, 0.099, 0.001, 0.5},
+			[]int{1, 2, 2, 2, 2},
+			[]int{0, 0, 0, 1, 1},
+			[]bool{false, false, false, true, true},
+		},
+		{
+			"explosion finishes after its last frame is shown",
+			GetAnimationClip("explosion"),
+			[]float64{0.3, 0.099, 0.001},
+			[]int{3, 3, 3},
+			[]int{0, 0, 1},
+			[]bool{false, false, true},
+		},
+		{
+			"ping-pong completes on returning to the first frame",
+			clip(AnimationPingPong, 100*ms, 100*ms, 100*ms),
+			[]float64{0.1, 0.1, 0.1, 0.1, 0.1},
+			[]int{1, 2, 1, 0, 1},
+			[]int{0, 0, 0, 1, 1},
+			[]bool{false, false, false, false, false},
+		},
+		{
+			"single frame ping-pong",
+			clip(AnimationPingPong, 100*ms),
+			[]float64{0.1, 0.1},
+			[]int{0, 0},
+			[]int{1, 2},
+			[]bool{false, false},
+		},
+		{
+			"large dt skips frames in a loop",
+			clip(AnimationLoop, 100*ms, 100*ms, 100*ms, 100*ms),
+			[]float64{0.65},
+			[]int{2},
+			[]int{1},
+			[]bool{false},
+		},
+		{
+			"large dt finishes a once clip once",
+			clip(AnimationOnce, 100*ms, 100*ms, 100*ms),
+			[]float64{1},
+			[]int{2},
+			[]int{1},
+			[]bool{true},
+		},
+		{
+			"large dt through a ping-pong",
+			clip(AnimationPingPong, 100*ms, 100*ms, 100*ms),
+			[]float64{0.85},
+			[]int{0},
+			[]int{2},
+			[]bool{false},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newComponentAnimationData(tt.clip, 4)
+			completions := 0
+			a.OnComplete = func() { completions++ }
+			for i, dt := range tt.dts {
+				a.Advance(dt)
+				if a.Frame != tt.frames[i] || completions != tt.completions[i] || a.Done != tt.done[i] {
+					t.Errorf("after call %d: frame %d, %d completions, done %v; want frame %d, %d completions, done %v",
+						i, a.Frame, completions, a.Done, tt.frames[i], tt.completions[i], tt.done[i])
+				}
+			}
+		})
+	}
+}
+
+func TestComponentAnimationDataDefaultsAndReset(t *testing.T) {
+	a := newComponentAnimationData(NewAnimationClip(AnimationOnce, 7, 8), 6)
+	if want := 100 * time.Millisecond; a.Durations[0] != want || a.Durations[1] != want {
+		t.Errorf("Durations = %v, want the default %v for 6 ticks", a.Durations, want)
+	}
+	if got := a.Frames; len(got) != 2 || got[0] != 7 || got[1] != 8 {
+		t.Errorf("Frames = %v, want [7 8]", got)
+	}
+
+	a.Advance(0.2)
+	if !a.Done {
+		t.Fatal("once clip is not done")
+	}
+	a.Reset()
+	if a.Frame != 0 || a.Done {
+		t.Errorf("after Reset: frame %d, done %v", a.Frame, a.Done)
+	}
+	a.Advance(0.1)
+	if a.Frame != 1 || a.Done {
+		t.Errorf("after Reset and 0.1s: frame %d, done %v; want frame 1, not done", a.Frame, a.Done)
+	}
+}
@@ -0,0 +1,233 @@
+package zelduh
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+)
+
+// AssetLoader runs asset loading tasks in background goroutines, and reports
+// their progress
+//
+// Values stored by a task may be read once Done returns true.
+type AssetLoader struct {
+	mu    sync.Mutex
+	total int
+	done  int
+	err   error
+}
+
+// NewAssetLoader returns a new AssetLoader with no tasks
+func NewAssetLoader() *AssetLoader {
+	return &AssetLoader{}
+}
+
+// Go runs the task in a new goroutine
+//
+// A task that panics fails with the panic value as its error.
+func (l *AssetLoader) Go(task func() error) {
+	l.mu.Lock()
+	l.total++
+	l.mu.Unlock()
+
+	go func() {
+		err := runTask(task)
+
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		l.done++
+		if err != nil && l.err == nil {
+			l.err = err
+		}
+	}()
+}
+
+func runTask(task func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	return task()
+}
+
+// Progress returns the number of tasks finished, and the number started
+func (l *AssetLoader) Progress() (done, total int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.done, l.total
+}
+
+// Done returns true once every task started has finished, whether or not it failed
+func (l *AssetLoader) Done() bool {
+	done, total := l.Progress()
+	return done == total
+}
+
+// Err returns the error of the first task that failed, if any
+func (l *AssetLoader) Err() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.err
+}
+
+// mapEntry is a map that is loaded, or being loaded
+type mapEntry struct {
+	ready chan struct{}
+	data  MapData
+	err   error
+}
+
+// MapStore loads tilemaps by name the first time they are needed, and keeps them
+//
+// Maps are read from dir, from a TMX file named after the map. A map that
+// places an unknown preset, sets an unknown property, or warps to a room that
+// is not one of its rooms, fails to load.
+type MapStore struct {
+	dir      string
+	tileSize float64
+
+	mu       sync.Mutex
+	maps     map[string]*mapEntry
+	tilesets map[string]map[int]tileProperties
+	rooms    map[RoomID]bool
+}
+
+// NewMapStore returns a new MapStore with no maps loaded
+func NewMapStore(dir string, tileSize float64) *MapStore {
+	return &MapStore{
+		dir:      dir,
+		tileSize: tileSize,
+		maps:     map[string]*mapEntry{},
+		tilesets: map[string]map[int]tileProperties{},
+	}
+}
+
+// SetRooms sets the rooms that warps placed in maps can lead to
+//
+// Until it is called, the rooms warps lead to are not checked. Maps already
+// loaded are not checked again.
+func (s *MapStore) SetRooms(rooms Rooms) {
+	ids := map[RoomID]bool{}
+	for id := range rooms {
+		ids[id] = true
+	}
+	s.mu.Lock()
+	s.rooms = ids
+	s.mu.Unlock()
+}
+
+// Preload starts loading the maps in the background
+//
+// If loader is not nil, the maps are loaded as its tasks, so it tracks their
+// progress. Maps already loaded, or being loaded, are skipped.
+func (s *MapStore) Preload(loader *AssetLoader, names ...string) {
+	for _, name := range names {
+		entry, start := s.entry(name)
+		if !start {
+			continue
+		}
+		name := name
+		task := func() error {
+			s.load(entry, name)
+			return entry.err
+		}
+		if loader != nil {
+			loader.Go(task)
+		} else {
+			go task()
+		}
+	}
+}
+
+// Get returns the named map, loading it first if needed
+//
+// If the map is being loaded in the background, Get waits for it. A map that
+// fails to load returns the same error every time.
+func (s *MapStore) Get(name string) (MapData, error) {
+	entry, start := s.entry(name)
+	if start {
+		s.load(entry, name)
+	}
+	<-entry.ready
+	return entry.data, entry.err
+}
+
+// Loaded returns true if the named map has finished loading
+func (s *MapStore) Loaded(name string) bool {
+	s.mu.Lock()
+	entry, ok := s.maps[name]
+	s.mu.Unlock()
+	if !ok {
+		return false
+	}
+	select {
+	case <-entry.ready:
+		return entry.err == nil
+	default:
+		return false
+	}
+}
+
+// entry returns the entry of the named map, and true if the caller must load it
+func (s *MapStore) entry(name string) (*mapEntry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if entry, ok := s.maps[name]; ok {
+		return entry, false
+	}
+	entry := &mapEntry{ready: make(chan struct{})}
+	s.maps[name] = entry
+	return entry, true
+}
+
+func (s *MapStore) load(entry *mapEntry, name string) {
+	defer close(entry.ready)
+	entry.err = runTask(func() error {
+		path := fmt.Sprintf("%s%s.tmx", s.dir, name)
+		tmxMap, extras, err := parseTmxFile(path)
+		if err != nil {
+			return err
+		}
+		tiles, err := mapTileProperties(filepath.Dir(path), extras.Tilesets, s.tileset)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		entry.data, err = buildMapData(name, tmxMap, s.tileSize, tiles)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		s.mu.Lock()
+		rooms := s.rooms
+		s.mu.Unlock()
+		configs, err := buildMapEntityConfigs(tmxMap, extras, s.tileSize, rooms)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		entry.data.EntityConfigs = configs
+		return nil
+	})
+}
+
+// tileset returns the tile properties of the TSX tileset at path, reading it
+// the first time it is needed
+//
+// Maps share tilesets, so they are kept for every map loaded after.
+func (s *MapStore) tileset(path string) (map[int]tileProperties, error) {
+	s.mu.Lock()
+	tiles, ok := s.tilesets[path]
+	s.mu.Unlock()
+	if ok {
+		return tiles, nil
+	}
+
+	tiles, err := parseTsxFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tilesets[path] = tiles
+	return tiles, nil
+}
@@ -0,0 +1,76 @@
+package zelduh
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/faiface/pixel"
+)
+
+// The tests in this file are meant to be run with -race
+
+func TestMapStoreConcurrentPreloadAndGet(t *testing.T) {
+	names := []string{"overworldOpen", "overworldFourWallsDoorBottomRight", "overworldFourWallsDoorLeft", "dungeonFourDoors"}
+	maps := NewMapStore("assets/tilemaps/", 48)
+	loader := NewAssetLoader()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			maps.Preload(loader, names...)
+		}()
+		go func() {
+			defer wg.Done()
+			for _, name := range names {
+				mapData, err := maps.Get(name)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if mapData.Name != name || len(mapData.Data) == 0 {
+					t.Errorf("Get(%s) = map %q with %d tiles", name, mapData.Name, len(mapData.Data))
+				}
+				maps.Loaded(name)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, name := range names {
+		if !maps.Loaded(name) {
+			t.Errorf("Loaded(%s) = false", name)
+		}
+	}
+}
+
+func TestLoadSpritesheetReadWhileLoading(t *testing.T) {
+	loader := NewAssetLoader()
+	spritesheet := LoadSpritesheet(loader, "assets/spritesheet.png", 48)
+
+	// The render system may draw while the spritesheet is still loading
+	for !loader.Done() {
+		spritesheet.Sprite(1)
+	}
+	if err := loader.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := spritesheet.Sprite(1); !ok {
+		t.Error("Sprite(1) is missing after loading")
+	}
+	if _, ok := spritesheet.Sprite(0); ok {
+		t.Error("Sprite(0) exists; sprite IDs start at 1")
+	}
+}
+
+func TestSpritesheetSprite(t *testing.T) {
+	sprite := &pixel.Sprite{}
+	spritesheet := NewSpritesheet(map[int]*pixel.Sprite{7: sprite})
+	if got, ok := spritesheet.Sprite(7); !ok || got != sprite {
+		t.Errorf("Sprite(7) = %v, %v", got, ok)
+	}
+	if _, ok := spritesheet.Sprite(8); ok {
+		t.Error("Sprite(8) exists")
+	}
+}
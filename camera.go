@@ -0,0 +1,97 @@
+package zelduh
+
+import "github.com/faiface/pixel"
+
+// Camera shows the current room in the map viewport
+//
+// On each axis, a room smaller than the viewport is centered in it, and a room
+// larger than the viewport scrolls to follow a target, such as the player,
+// without showing anything past the room's edges.
+type Camera struct {
+	// Viewport is the largest rectangle of the screen a room is shown in
+	Viewport MapConfig
+
+	room     pixel.Rect
+	position pixel.Vec
+}
+
+// NewCamera returns a new Camera showing a room the size of the viewport
+func NewCamera(viewport MapConfig) *Camera {
+	return &Camera{
+		Viewport: viewport,
+		room:     pixel.R(0, 0, viewport.Width, viewport.Height),
+	}
+}
+
+// SetRoom sets the bounds of the room shown, in room coordinates
+func (c *Camera) SetRoom(bounds pixel.Rect) {
+	c.room = bounds
+	c.Follow(c.position.Add(pixel.V(c.Viewport.Width/2, c.Viewport.Height/2)))
+}
+
+// Room returns the bounds of the room shown
+func (c *Camera) Room() pixel.Rect {
+	return c.room
+}
+
+// Follow scrolls the camera to center the target, in room coordinates, as far
+// as the room allows
+func (c *Camera) Follow(target pixel.Vec) {
+	c.position = pixel.V(
+		cameraAxis(target.X, c.room.Min.X, c.room.Max.X, c.Viewport.Width),
+		cameraAxis(target.Y, c.room.Min.Y, c.room.Max.Y, c.Viewport.Height),
+	)
+}
+
+// cameraAxis returns the room coordinate shown at the start of the viewport,
+// on one axis
+func cameraAxis(target, min, max, viewport float64) float64 {
+	if max-min <= viewport {
+		return min
+	}
+	start := target - viewport/2
+	if start < min {
+		return min
+	}
+	if start > max-viewport {
+		return max - viewport
+	}
+	return start
+}
+
+// Screen returns the rectangle of the screen the room is shown in
+func (c *Camera) Screen() MapConfig {
+	screen := c.Viewport
+	if w := c.room.W(); w < screen.Width {
+		screen.X += (screen.Width - w) / 2
+		screen.Width = w
+	}
+	if h := c.room.H(); h < screen.Height {
+		screen.Y += (screen.Height - h) / 2
+		screen.Height = h
+	}
+	return screen
+}
+
+// Offset returns what is added to room coordinates to get screen coordinates
+func (c *Camera) Offset() pixel.Vec {
+	screen := c.Screen()
+	return pixel.V(screen.X, screen.Y).Sub(c.position)
+}
+
+// View returns the transform from room coordinates to screen coordinates
+func (c *Camera) View() pixel.Matrix {
+	return pixel.IM.Moved(c.Offset())
+}
+
+// mapConfigUnion returns the smallest rectangle that holds both a and b
+func mapConfigUnion(a, b MapConfig) MapConfig {
+	r := pixel.R(a.X, a.Y, a.X+a.Width, a.Y+a.Height).
+		Union(pixel.R(b.X, b.Y, b.X+b.Width, b.Y+b.Height))
+	return MapConfig{
+		X:      r.Min.X,
+		Y:      r.Min.Y,
+		Width:  r.W(),
+		Height: r.H(),
+	}
+}
@@ -0,0 +1,66 @@
+package zelduh
+
+import (
+	"testing"
+
+	"github.com/faiface/pixel"
+)
+
+func TestCameraAxis(t *testing.T) {
+	tests := []struct {
+		name                       string
+		target, min, max, viewport float64
+		want                       float64
+	}{
+		{"room smaller than the viewport", 100, 0, 480, 672, 0},
+		{"room as big as the viewport", 600, 0, 672, 672, 0},
+		{"target near the start", 100, 0, 1344, 672, 0},
+		{"target in the middle", 700, 0, 1344, 672, 364},
+		{"target near the end", 1300, 0, 1344, 672, 672},
+		{"room not at the origin", 200, 100, 1444, 672, 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cameraAxis(tt.target, tt.min, tt.max, tt.viewport); got != tt.want {
+				t.Errorf("cameraAxis = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCameraScreenAndOffset(t *testing.T) {
+	viewport := DefaultConfig().MapConfig()
+	if viewport != (MapConfig{X: 64, Y: 112, Width: 672, Height: 576}) {
+		t.Fatalf("default viewport = %+v", viewport)
+	}
+
+	tests := []struct {
+		name       string
+		room       pixel.Rect
+		target     pixel.Vec
+		wantScreen MapConfig
+		wantOffset pixel.Vec
+	}{
+		{"room as big as the viewport", pixel.R(0, 0, 672, 576), pixel.V(600, 500), viewport, pixel.V(64, 112)},
+		{"smaller room is centered", pixel.R(0, 0, 480, 384), pixel.V(100, 100), MapConfig{X: 160, Y: 208, Width: 480, Height: 384}, pixel.V(160, 208)},
+		{"larger room follows the target", pixel.R(0, 0, 1344, 1152), pixel.V(1000, 900), viewport, pixel.V(64-664, 112-576)},
+		{"larger room stops at its edges", pixel.R(0, 0, 1344, 1152), pixel.V(10, 1150), viewport, pixel.V(64, 112-576)},
+		{"wide but short room", pixel.R(0, 0, 1344, 384), pixel.V(700, 100), MapConfig{X: 64, Y: 208, Width: 672, Height: 384}, pixel.V(64-364, 208)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			camera := NewCamera(viewport)
+			camera.SetRoom(tt.room)
+			camera.Follow(tt.target)
+			if got := camera.Screen(); got != tt.wantScreen {
+				t.Errorf("Screen = %+v, want %+v", got, tt.wantScreen)
+			}
+			if got := camera.Offset(); got != tt.wantOffset {
+				t.Errorf("Offset = %v, want %v", got, tt.wantOffset)
+			}
+			if got := camera.View().Project(pixel.ZV); got != tt.wantOffset {
+				t.Errorf("View moves the room origin to %v, want %v", got, tt.wantOffset)
+			}
+		})
+	}
+}
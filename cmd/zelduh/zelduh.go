@@ -1,9 +1,9 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	_ "image/png"
-	"math/rand"
 	"os"
 	"time"
 
@@ -14,34 +14,27 @@ import (
 
 const tilemapDir = "assets/tilemaps/"
 const spritesheetPath = "assets/spritesheet.png"
+const worldPath = "assets/world.json"
 
-func run() {
-
-	// frameRate is used to determine which sprite to use for animations
-	const frameRate int = 5
+// configPath is an optional JSON file overriding zelduh.DefaultConfig
+var configPath = flag.String("config", "", "path to a JSON engine config file")
 
-	const tileSize float64 = 48
+// seed is the seed for all game randomness; see zelduh.Random for what it replays
+var seed = flag.Int64("seed", 0, "random seed (default: based on the current time)")
 
-	var windowConfig zelduh.WindowConfig = zelduh.WindowConfig{
-		X:      0,
-		Y:      0,
-		Width:  800,
-		Height: 800,
-	}
-
-	var mapConfig zelduh.MapConfig = zelduh.MapConfig{
-		Width:  tileSize * 14,
-		Height: tileSize * 12,
-	}
+// profilePath, if set, turns the profiler on and is where its report is written on exit
+var profilePath = flag.String("profile", "", "profile systems and frames, and write the report as JSON to this file on exit")
 
-	mapConfig.X = (windowConfig.Width - mapConfig.Width) / 2
-	mapConfig.Y = (windowConfig.Height - mapConfig.Height) / 2
+func run() {
 
-	mapBoundsConfig := zelduh.MapBoundsConfig{
-		X:      mapConfig.X,
-		Y:      mapConfig.Y,
-		Width:  mapConfig.X + mapConfig.Width,
-		Height: mapConfig.Y + mapConfig.Height,
+	config := zelduh.DefaultConfig()
+	if *configPath != "" {
+		var err error
+		config, err = zelduh.LoadConfig(*configPath)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
 	}
 
 	currLocaleMsgs, err := zelduh.GetLocaleMessageMapByLanguage("en")
@@ -50,108 +43,102 @@ func run() {
 		os.Exit(0)
 	}
 
-	systemsManager := zelduh.NewSystemsManager()
-
-	zelduh.BuildMapRoomIDToRoom(zelduh.Overworld, zelduh.RoomsMap)
-
-	ui := zelduh.NewUI(currLocaleMsgs, windowConfig)
-
-	allMapDrawData := zelduh.BuildMapDrawData(tilemapDir, zelduh.TilemapFiles, zelduh.TileSize)
-
-	roomData := zelduh.NewRoomData()
-
-	roomTransitionManager := zelduh.NewRoomTransitionManager()
-
-	entities := zelduh.Entities{
-		Player:    zelduh.BuildEntityFromConfig(zelduh.GetPreset("player")(6, 6), systemsManager.NewEntityID(), frameRate),
-		Bomb:      zelduh.BuildEntityFromConfig(zelduh.GetPreset("bomb")(0, 0), systemsManager.NewEntityID(), frameRate),
-		Explosion: zelduh.BuildEntityFromConfig(zelduh.GetPreset("explosion")(0, 0), systemsManager.NewEntityID(), frameRate),
-		Sword:     zelduh.BuildEntityFromConfig(zelduh.GetPreset("sword")(0, 0), systemsManager.NewEntityID(), frameRate),
-		Arrow:     zelduh.BuildEntityFromConfig(zelduh.GetPreset("arrow")(0, 0), systemsManager.NewEntityID(), frameRate),
-		Hearts: zelduh.BuildEntitiesFromConfigs(
-			systemsManager.NewEntityID,
-			frameRate,
-			zelduh.GetPreset("heart")(1.5, 14),
-			zelduh.GetPreset("heart")(2.15, 14),
-			zelduh.GetPreset("heart")(2.80, 14),
-		),
+	if !flagSet("seed") {
+		*seed = time.Now().UnixNano()
 	}
+	fmt.Printf("seed: %d\n", *seed)
 
-	healthSystem := &zelduh.SystemHealth{}
+	ui, err := zelduh.NewUI(currLocaleMsgs, config.WindowConfig())
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
 
-	spatialSystem := &zelduh.SystemSpatial{
-		Rand: rand.New(rand.NewSource(time.Now().UnixNano())),
+	gameStateManager, err := zelduh.NewGame(zelduh.GameConfig{
+		Config:          config,
+		Seed:            *seed,
+		UI:              ui,
+		LocaleMessages:  currLocaleMsgs,
+		WorldPath:       worldPath,
+		TilemapDir:      tilemapDir,
+		SpritesheetPath: spritesheetPath,
+	})
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
 	}
 
-	entitiesMap := zelduh.NewEntityByEntityIDMap()
-
-	roomWarps := map[zelduh.EntityID]zelduh.EntityConfig{}
-
-	collisionSystem := zelduh.NewSystemCollision(
-		mapBoundsConfig,
-		&roomTransitionManager,
-		&systemsManager,
-		healthSystem,
-		spatialSystem,
-		entitiesMap,
-		roomWarps,
-		entities,
-	)
-
-	inputSystem := &zelduh.SystemInput{Win: ui.Window}
-
-	spritesheet := zelduh.LoadAndBuildSpritesheet(spritesheetPath, zelduh.TileSize)
-
-	systemsManager.AddSystems(
-		inputSystem,
-		healthSystem,
-		spatialSystem,
-		&collisionSystem,
-		&zelduh.SystemRender{
-			Win:         ui.Window,
-			Spritesheet: spritesheet,
-		},
-	)
-
-	systemsManager.AddEntities(
-		entities.Player,
-		entities.Sword,
-		entities.Arrow,
-		entities.Bomb,
-	)
-
-	gameStateManager := zelduh.NewGameStateManager(
-		&roomTransitionManager,
-		&systemsManager,
-		ui,
-		currLocaleMsgs,
-		&collisionSystem,
-		inputSystem,
-		spritesheet,
-		entitiesMap,
-		allMapDrawData,
-		roomWarps,
-		entities,
-		&roomData,
-		mapConfig,
-		windowConfig,
-		frameRate,
-	)
+	profiler := &gameStateManager.SystemsManager.Profiler
+	profiler.Enabled = *profilePath != ""
+	showProfile := false
 
+	last := time.Now()
 	for !ui.Window.Closed() {
 
 		// Quit application when user input matches
 		if ui.Window.JustPressed(pixelgl.KeyQ) {
+			writeProfile(profiler)
 			os.Exit(1)
 		}
 
-		gameStateManager.Update()
+		// F3 shows the profiler overlay, profiling from then on
+		if ui.Window.JustPressed(pixelgl.KeyF3) {
+			showProfile = !showProfile
+			if showProfile {
+				profiler.Enabled = true
+			}
+		}
+
+		profiler.BeginFrame()
+
+		// Frame time drives the fixed-rate simulation; the frame rate itself is set by VSync
+		now := time.Now()
+		dt := now.Sub(last).Seconds()
+		last = now
+
+		gameStateManager.Update(dt)
+
+		if showProfile {
+			zelduh.DrawProfileOverlay(ui.Window, ui.Text, profiler.Report())
+		}
 
 		ui.Window.Update()
 
+		profiler.EndFrame()
+	}
+	writeProfile(profiler)
+}
+
+// writeProfile writes the profiler report to profilePath, if it is set
+func writeProfile(profiler *zelduh.Profiler) {
+	if *profilePath == "" {
+		return
+	}
+	f, err := os.Create(*profilePath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return
+	}
+	if err := profiler.WriteJSON(f); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	if err := f.Close(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
 	}
 }
 
 func main() {
+	flag.Parse()
 	pixelgl.Run(run)
 }
+
+// flagSet returns true if the named flag was passed on the command line
+func flagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
@@ -1,28 +1,99 @@
 package zelduh
 
-import (
-	"github.com/faiface/pixel"
-)
-
 // CollisionHandler contains collision handlers
+//
+// The health and spatial systems it uses are those registered with the
+// SystemsManager as SystemNameHealth and SystemNameSpatial.
 type CollisionHandler struct {
 	RoomTransitionManager *RoomTransitionManager
 	SystemsManager        *SystemsManager
-	HealthSystem          *SystemHealth
-	SpatialSystem         *SystemSpatial
-	EntitiesMap           EntityByEntityID
 	RoomWarps             map[EntityID]EntityConfig
 	Entities              Entities
-	GameStateManager      GameStateManager
-	RoomData              RoomData
-	FrameRate             int
+	GameStateManager      *GameStateManager
+	RoomData              *RoomData
+}
+
+// NewCollisionHandler returns a new CollisionHandler
+func NewCollisionHandler(
+	roomTransitionManager *RoomTransitionManager,
+	systemsManager *SystemsManager,
+	roomWarps map[EntityID]EntityConfig,
+	entities Entities,
+	roomData *RoomData,
+) CollisionHandler {
+	return CollisionHandler{
+		RoomTransitionManager: roomTransitionManager,
+		SystemsManager:        systemsManager,
+		RoomWarps:             roomWarps,
+		Entities:              entities,
+		RoomData:              roomData,
+	}
+}
+
+// Subscribe registers the collision handler with the event bus
+//
+// GameStateManager must be set before the first event is dispatched.
+func (ch *CollisionHandler) Subscribe(events *EventBus) {
+	events.Subscribe(EventTypeCollision, ch.OnCollision)
+	events.Subscribe(EventTypeEnemyKilled, ch.OnEnemyKilled)
+}
+
+// healthSystem returns the system registered as SystemNameHealth, if it is a
+// SystemHealth
+func (ch *CollisionHandler) healthSystem() (*SystemHealth, bool) {
+	sys, _ := ch.SystemsManager.System(SystemNameHealth)
+	health, ok := sys.(*SystemHealth)
+	return health, ok
+}
+
+// spatialSystem returns the system registered as SystemNameSpatial, if it is a
+// SystemSpatial
+func (ch *CollisionHandler) spatialSystem() (*SystemSpatial, bool) {
+	sys, _ := ch.SystemsManager.System(SystemNameSpatial)
+	spatial, ok := sys.(*SystemSpatial)
+	return spatial, ok
+}
+
+// OnCollision routes a CollisionEvent to the handler for its kind
+func (ch *CollisionHandler) OnCollision(event Event) {
+	e := event.(CollisionEvent)
+	switch e.Kind {
+	case CollisionPlayerWithBounds:
+		ch.OnPlayerCollisionWithBounds(e.Bound)
+	case CollisionPlayerWithCoin:
+		ch.OnPlayerCollisionWithCoin(e.OtherID)
+	case CollisionPlayerWithEnemy:
+		ch.OnPlayerCollisionWithEnemy(e.OtherID)
+	case CollisionSwordWithEnemy:
+		ch.OnSwordCollisionWithEnemy(e.OtherID)
+	case CollisionArrowWithEnemy:
+		ch.OnArrowCollisionWithEnemy(e.OtherID)
+	case CollisionArrowWithObstacle:
+		ch.OnArrowCollisionWithObstacle()
+	case CollisionPlayerWithObstacle:
+		ch.OnPlayerCollisionWithObstacle(e.OtherID)
+	case CollisionPlayerWithMoveableObstacle:
+		ch.OnPlayerCollisionWithMoveableObstacle(e.OtherID)
+	case CollisionMoveableObstacleWithSwitch:
+		ch.OnMoveableObstacleCollisionWithSwitch(e.OtherID)
+	case CollisionMoveableObstacleNoSwitch:
+		ch.OnMoveableObstacleNoCollisionWithSwitch(e.OtherID)
+	case CollisionEnemyWithObstacle:
+		ch.OnEnemyCollisionWithObstacle(e.EntityID, e.OtherID)
+	case CollisionPlayerWithSwitch:
+		ch.OnPlayerCollisionWithSwitch(e.OtherID)
+	case CollisionPlayerNoSwitch:
+		ch.OnPlayerNoCollisionWithSwitch(e.OtherID)
+	case CollisionPlayerWithWarp:
+		ch.OnPlayerCollisionWithWarp(e.OtherID)
+	}
 }
 
 // OnPlayerCollisionWithBounds handles collisions between player and bounds
 func (ch *CollisionHandler) OnPlayerCollisionWithBounds(side Bound) {
 	if !ch.RoomTransitionManager.Active() {
 		ch.RoomTransitionManager.SetSlideStart(side)
-		ch.GameStateManager.CurrentState = StateMapTransition
+		ch.GameStateManager.Change(StateMapTransition)
 		ch.SystemsManager.SetShouldAddEntities(true)
 	}
 }
@@ -30,81 +101,98 @@ func (ch *CollisionHandler) OnPlayerCollisionWithBounds(side Bound) {
 // OnPlayerCollisionWithCoin handles collision between player and coin
 func (ch *CollisionHandler) OnPlayerCollisionWithCoin(coinID EntityID) {
 	ch.Entities.Player.ComponentCoins.Coins++
-	ch.SystemsManager.Remove(CategoryCoin, coinID)
+	ch.SystemsManager.Commands.Destroy(coinID)
+	ch.SystemsManager.Events.Publish(CoinCollectedEvent{
+		CoinID: coinID,
+		Total:  ch.Entities.Player.ComponentCoins.Coins,
+	})
 }
 
 // OnPlayerCollisionWithEnemy handles collision between player and enemy
 func (ch *CollisionHandler) OnPlayerCollisionWithEnemy(enemyID EntityID) {
 	// TODO repeat what I did with the enemies
-	ch.SpatialSystem.MovePlayerBack()
+	if spatial, ok := ch.spatialSystem(); ok {
+		spatial.MovePlayerBack()
+	}
 	ch.Entities.Player.ComponentHealth.Total--
 
 	// remove heart entity
 	heartIndex := len(ch.Entities.Hearts) - 1
-	ch.SystemsManager.Remove(CategoryHeart, ch.Entities.Hearts[heartIndex].ID())
+	ch.SystemsManager.Commands.Destroy(ch.Entities.Hearts[heartIndex].ID())
 	ch.Entities.Hearts = append(ch.Entities.Hearts[:heartIndex], ch.Entities.Hearts[heartIndex+1:]...)
 
+	ch.SystemsManager.Events.Publish(PlayerDamagedEvent{
+		SourceID: enemyID,
+		Health:   ch.Entities.Player.ComponentHealth.Total,
+	})
+
 	if ch.Entities.Player.ComponentHealth.Total == 0 {
-		ch.GameStateManager.CurrentState = StateOver
+		ch.GameStateManager.Change(StateOver)
 	}
 }
 
-func dropCoin(v pixel.Vec, systemsManager *SystemsManager, frameRate int) {
-	coin := BuildEntityFromConfig(GetPreset("coin")(v.X/TileSize, v.Y/TileSize), systemsManager.NewEntityID(), frameRate)
-	systemsManager.AddEntity(coin)
-}
-
 // OnSwordCollisionWithEnemy handles collision between sword and enemy
 func (ch *CollisionHandler) OnSwordCollisionWithEnemy(enemyID EntityID) {
+	spatial, ok := ch.spatialSystem()
+	if !ok {
+		return
+	}
 	if !ch.Entities.Sword.ComponentIgnore.Value {
-		dead := false
-		if !ch.SpatialSystem.EnemyMovingFromHit(enemyID) {
-			dead = ch.HealthSystem.Hit(enemyID, 1)
-			if dead {
-				enemySpatial, _ := ch.SpatialSystem.GetEnemySpatial(enemyID)
-				ch.Entities.Explosion.ComponentTemporary.Expiration = len(ch.Entities.Explosion.ComponentAnimation.Map["default"].Frames)
-				ch.Entities.Explosion.ComponentSpatial = &ComponentSpatial{
-					Width:  TileSize,
-					Height: TileSize,
-					Rect:   enemySpatial.Rect,
-				}
-				ch.Entities.Explosion.ComponentTemporary.OnExpiration = func() {
-					dropCoin(ch.Entities.Explosion.ComponentSpatial.Rect.Min, ch.SystemsManager, ch.FrameRate)
-				}
-				ch.SystemsManager.AddEntity(ch.Entities.Explosion)
-				ch.SystemsManager.RemoveEnemy(enemyID)
-			} else {
-				ch.SpatialSystem.MoveEnemyBack(enemyID, ch.Entities.Player.ComponentMovement.Direction)
-			}
+		if !spatial.EnemyMovingFromHit(enemyID) {
+			ch.hitEnemy(enemyID)
 		}
-
 	}
 }
 
 // OnArrowCollisionWithEnemy handles collision between arrow and enemy
 func (ch *CollisionHandler) OnArrowCollisionWithEnemy(enemyID EntityID) {
 	if !ch.Entities.Arrow.ComponentIgnore.Value {
-		dead := ch.HealthSystem.Hit(enemyID, 1)
 		ch.Entities.Arrow.ComponentIgnore.Value = true
-		if dead {
-			enemySpatial, _ := ch.SpatialSystem.GetEnemySpatial(enemyID)
-			ch.Entities.Explosion.ComponentTemporary.Expiration = len(ch.Entities.Explosion.ComponentAnimation.Map["default"].Frames)
-			ch.Entities.Explosion.ComponentSpatial = &ComponentSpatial{
-				Width:  TileSize,
-				Height: TileSize,
-				Rect:   enemySpatial.Rect,
-			}
-			ch.Entities.Explosion.ComponentTemporary.OnExpiration = func() {
-				dropCoin(ch.Entities.Explosion.ComponentSpatial.Rect.Min, ch.SystemsManager, ch.FrameRate)
-			}
-			ch.SystemsManager.AddEntity(ch.Entities.Explosion)
-			ch.SystemsManager.RemoveEnemy(enemyID)
-		} else {
-			ch.SpatialSystem.MoveEnemyBack(enemyID, ch.Entities.Player.ComponentMovement.Direction)
-		}
+		ch.hitEnemy(enemyID)
 	}
 }
 
+func (ch *CollisionHandler) hitEnemy(enemyID EntityID) {
+	health, ok := ch.healthSystem()
+	if !ok {
+		return
+	}
+	spatial, ok := ch.spatialSystem()
+	if !ok {
+		return
+	}
+	// The enemy may already have been killed earlier in this tick, and be
+	// waiting to be destroyed
+	if !health.Alive(enemyID) {
+		return
+	}
+	dead := health.Hit(enemyID, 1)
+	if dead {
+		enemySpatial, _ := spatial.GetEnemySpatial(enemyID)
+		ch.SystemsManager.Events.Publish(EnemyKilledEvent{
+			EnemyID: enemyID,
+			Rect:    enemySpatial.Rect,
+		})
+		ch.SystemsManager.Commands.Destroy(enemyID)
+	} else {
+		spatial.MoveEnemyBack(enemyID, ch.Entities.Player.ComponentMovement.Direction)
+		ch.SystemsManager.Events.Publish(EnemyHitEvent{
+			EnemyID: enemyID,
+			Damage:  1,
+		})
+	}
+}
+
+// OnEnemyKilled shows an explosion where the enemy died, which drops a coin when it expires
+func (ch *CollisionHandler) OnEnemyKilled(event Event) {
+	e := event.(EnemyKilledEvent)
+	ch.Entities.Explosions.Spawn(e.Rect.Min, func(explosion *Entity) {
+		explosion.ComponentTemporary.OnExpiration = func() {
+			ch.Entities.Coins.Spawn(explosion.ComponentSpatial.Rect.Min, nil)
+		}
+	})
+}
+
 // OnArrowCollisionWithObstacle handles collision between arrow and obstacle
 func (ch *CollisionHandler) OnArrowCollisionWithObstacle() {
 	ch.Entities.Arrow.ComponentMovement.RemainingMoves = 0
@@ -119,7 +207,11 @@ func (ch *CollisionHandler) OnPlayerCollisionWithObstacle(obstacleID EntityID) {
 
 // OnPlayerCollisionWithMoveableObstacle handles collision between player and moveable obstacle
 func (ch *CollisionHandler) OnPlayerCollisionWithMoveableObstacle(obstacleID EntityID) {
-	moved := ch.SpatialSystem.MoveMoveableObstacle(obstacleID, ch.Entities.Player.ComponentMovement.Direction)
+	spatial, ok := ch.spatialSystem()
+	if !ok {
+		return
+	}
+	moved := spatial.MoveMoveableObstacle(obstacleID, ch.Entities.Player.ComponentMovement.Direction)
 	if !moved {
 		ch.Entities.Player.ComponentSpatial.Rect = ch.Entities.Player.ComponentSpatial.PrevRect
 	}
@@ -127,44 +219,38 @@ func (ch *CollisionHandler) OnPlayerCollisionWithMoveableObstacle(obstacleID Ent
 
 // OnMoveableObstacleCollisionWithSwitch handles collision between moveable obstacle and switch
 func (ch *CollisionHandler) OnMoveableObstacleCollisionWithSwitch(collisionSwitchID EntityID) {
-	for id, entity := range ch.EntitiesMap {
-		if id == collisionSwitchID && !entity.ComponentToggler.Enabled() {
-			entity.ComponentToggler.Toggle()
-		}
-	}
+	ch.setSwitch(collisionSwitchID, true)
 }
 
 // OnMoveableObstacleNoCollisionWithSwitch handles *no* collision between moveable obstacle and switch
 func (ch *CollisionHandler) OnMoveableObstacleNoCollisionWithSwitch(collisionSwitchID EntityID) {
-	for id, entity := range ch.EntitiesMap {
-		if id == collisionSwitchID && entity.ComponentToggler.Enabled() {
-			entity.ComponentToggler.Toggle()
-		}
+	ch.setSwitch(collisionSwitchID, false)
+}
+
+// setSwitch turns the switch on or off, if it is not already
+func (ch *CollisionHandler) setSwitch(switchID EntityID, enabled bool) {
+	entity, ok := ch.SystemsManager.Entity(switchID)
+	if ok && entity.ComponentToggler != nil && entity.ComponentToggler.Enabled() != enabled {
+		entity.ComponentToggler.Toggle()
 	}
 }
 
 // OnEnemyCollisionWithObstacle handles collision between enemy and obstacle
 func (ch *CollisionHandler) OnEnemyCollisionWithObstacle(enemyID, obstacleID EntityID) {
 	// Block enemy within the spatial system by reseting current rect to previous rect
-	ch.SpatialSystem.UndoEnemyRect(enemyID)
+	if spatial, ok := ch.spatialSystem(); ok {
+		spatial.UndoEnemyRect(enemyID)
+	}
 }
 
 // OnPlayerCollisionWithSwitch handles collision between player and switch
 func (ch *CollisionHandler) OnPlayerCollisionWithSwitch(collisionSwitchID EntityID) {
-	for id, entity := range ch.EntitiesMap {
-		if id == collisionSwitchID && !entity.ComponentToggler.Enabled() {
-			entity.ComponentToggler.Toggle()
-		}
-	}
+	ch.setSwitch(collisionSwitchID, true)
 }
 
 // OnPlayerNoCollisionWithSwitch handles *no* collision between player and switch
 func (ch *CollisionHandler) OnPlayerNoCollisionWithSwitch(collisionSwitchID EntityID) {
-	for id, entity := range ch.EntitiesMap {
-		if id == collisionSwitchID && entity.ComponentToggler.Enabled() {
-			entity.ComponentToggler.Toggle()
-		}
-	}
+	ch.setSwitch(collisionSwitchID, false)
 }
 
 // OnPlayerCollisionWithWarp handles collision between player and warp
@@ -172,7 +258,7 @@ func (ch *CollisionHandler) OnPlayerCollisionWithWarp(warpID EntityID) {
 	entityConfig, ok := ch.RoomWarps[warpID]
 	if ok && !ch.RoomTransitionManager.Active() {
 		ch.RoomTransitionManager.SetWarp()
-		ch.GameStateManager.CurrentState = StateMapTransition
+		ch.GameStateManager.Change(StateMapTransition)
 		ch.SystemsManager.SetShouldAddEntities(true)
 		ch.RoomData.NextRoomID = entityConfig.WarpToRoomID
 	}
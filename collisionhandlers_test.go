@@ -0,0 +1,37 @@
+package zelduh
+
+import "testing"
+
+func TestCollisionHandlerUsesRegisteredSystems(t *testing.T) {
+	w := NewSystemsManager(1)
+	rtm := NewRoomTransitionManager(DefaultConfig())
+	roomData := NewRoomData()
+	player := BuildEntityFromConfig(MustGetPreset("player")(48, 5, 4), w.NewEntityID(), 4)
+	w.AddEntity(player)
+	ch := NewCollisionHandler(&rtm, &w, map[EntityID]EntityConfig{}, Entities{Player: player}, &roomData)
+
+	enemy := BuildEntityFromConfig(MustGetPreset("skull")(48, 3, 3), w.NewEntityID(), 4)
+	w.AddEntity(enemy)
+	got := []EventType{}
+	for _, et := range []EventType{EventTypeEnemyHit, EventTypeEnemyKilled} {
+		et := et
+		w.Events.Subscribe(et, func(Event) { got = append(got, et) })
+	}
+
+	// Without the systems, the enemy cannot be hit
+	ch.hitEnemy(enemy.ID())
+	w.Events.Dispatch()
+	if len(got) != 0 || enemy.ComponentHealth.Total != 2 {
+		t.Fatalf("hit without the systems: events %v, health %d", got, enemy.ComponentHealth.Total)
+	}
+
+	w.AddSystem(SystemNameHealth, SystemPhaseSimulation, &SystemHealth{SystemsManager: &w})
+	w.AddSystem(SystemNameSpatial, SystemPhaseSimulation, &SystemSpatial{SystemsManager: &w, TileSize: 48})
+	ch.hitEnemy(enemy.ID())
+	ch.hitEnemy(enemy.ID())
+	w.Events.Dispatch()
+	want := []EventType{EventTypeEnemyHit, EventTypeEnemyKilled}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+}
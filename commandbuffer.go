@@ -0,0 +1,57 @@
+package zelduh
+
+type entityCommandOp int
+
+const (
+	entityCommandSpawn entityCommandOp = iota
+	entityCommandDestroy
+)
+
+type entityCommand struct {
+	op     entityCommandOp
+	entity Entity
+	id     EntityID
+}
+
+// CommandBuffer queues entity spawns and destroys
+//
+// Systems and event handlers use it instead of adding or removing entities
+// directly, so the set of entities never changes while a system is iterating it.
+// The SystemsManager applies the queued commands, in order, between system updates.
+type CommandBuffer struct {
+	commands []entityCommand
+}
+
+// Spawn queues the entity to be added
+func (c *CommandBuffer) Spawn(entity Entity) {
+	c.commands = append(c.commands, entityCommand{
+		op:     entityCommandSpawn,
+		entity: entity,
+	})
+}
+
+// Destroy queues the entity to be removed
+func (c *CommandBuffer) Destroy(id EntityID) {
+	c.commands = append(c.commands, entityCommand{
+		op: entityCommandDestroy,
+		id: id,
+	})
+}
+
+// Len returns the number of queued commands
+func (c *CommandBuffer) Len() int {
+	return len(c.commands)
+}
+
+// apply runs the queued commands against the SystemsManager and empties the buffer
+func (c *CommandBuffer) apply(w *SystemsManager) {
+	for _, command := range c.commands {
+		switch command.op {
+		case entityCommandSpawn:
+			w.AddEntity(command.entity)
+		case entityCommandDestroy:
+			w.DestroyEntity(command.id)
+		}
+	}
+	c.commands = c.commands[:0]
+}
@@ -0,0 +1,59 @@
+package zelduh
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCommandBufferAppliesInOrder(t *testing.T) {
+	w := NewSystemsManager(1)
+	kept := Entity{id: w.NewEntityID(), Category: CategoryEnemy}
+	gone := Entity{id: w.NewEntityID(), Category: CategoryEnemy}
+
+	w.Commands.Spawn(kept)
+	w.Commands.Spawn(gone)
+	w.Commands.Destroy(gone.ID())
+	if w.Commands.Len() != 3 || w.Count(0, 0) != 0 {
+		t.Fatalf("commands applied before flush: %d queued, %d entities", w.Commands.Len(), w.Count(0, 0))
+	}
+
+	w.flush()
+
+	if got, want := entityIDs(w.Query(0, 0)), []EntityID{kept.ID()}; !equalIDs(got, want) {
+		t.Errorf("Query = %v, want %v", got, want)
+	}
+	if w.Commands.Len() != 0 {
+		t.Errorf("%d commands left after flush", w.Commands.Len())
+	}
+}
+
+func TestCommandsApplyAfterEventsBeforeNextSystem(t *testing.T) {
+	w := NewSystemsManager(1)
+	coin := Entity{id: w.NewEntityID(), Category: CategoryCoin}
+	got := []string{}
+
+	w.Events.Subscribe(EventTypeEnemyKilled, func(e Event) {
+		got = append(got, "handler")
+		w.Commands.Spawn(coin)
+	})
+	w.AddSystem("health", SystemPhaseSimulation, systemFunc(func(dt float64) {
+		got = append(got, "health")
+		w.Events.Publish(EnemyKilledEvent{})
+		if w.Count(CategoryCoin, 0) != 0 {
+			t.Error("coin spawned while the publishing system was running")
+		}
+	}))
+	w.AddSystem("collision", SystemPhaseCollision, systemFunc(func(dt float64) {
+		got = append(got, "collision")
+		if w.Count(CategoryCoin, 0) != 1 {
+			t.Error("coin was not spawned before the next system")
+		}
+	}))
+
+	w.tick(testDt)
+
+	want := []string{"health", "handler", "collision"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ran %v, want %v", got, want)
+	}
+}
@@ -2,22 +2,70 @@ package zelduh
 
 import (
 	"image/color"
+	"time"
 
 	"github.com/faiface/pixel"
 	"github.com/faiface/pixel/imdraw"
 )
 
+// ComponentType is a bit set of component kinds, used to query entities by the components they have
+type ComponentType uint
+
+const (
+	ComponentTypeAnimation = ComponentType(1 << iota)
+	ComponentTypeAppearance
+	ComponentTypeAttachment
+	ComponentTypeCoins
+	ComponentTypeDash
+	ComponentTypeEnabled
+	ComponentTypeHealth
+	ComponentTypeIgnore
+	ComponentTypeInvincible
+	ComponentTypeMovement
+	ComponentTypeSpatial
+	ComponentTypeTemporary
+	ComponentTypeToggler
+)
+
 // ComponentAppearance contains data about visual appearance
 type ComponentAppearance struct {
 	Color color.RGBA
 }
 
+// ComponentAttachment keeps an entity positioned relative to a parent entity
+//
+// While attached, the entity's rect is the parent's rect moved by the offset for
+// the direction the parent faces, and the entity faces the same way. While the
+// entity is ignored, such as the sword when it is not swung, it sits on the
+// parent instead.
+type ComponentAttachment struct {
+	Offsets map[Direction]pixel.Vec
+	parent  EntityID
+}
+
+// Attach makes the entity follow the parent
+func (s *ComponentAttachment) Attach(parent EntityID) {
+	s.parent = parent
+}
+
+// Detach stops the entity following its parent, leaving it where it is
+func (s *ComponentAttachment) Detach() {
+	s.parent = 0
+}
+
+// Parent returns the entity followed, if any
+func (s *ComponentAttachment) Parent() (EntityID, bool) {
+	return s.parent, s.parent != 0
+}
+
 // ComponentCoins contains info about an entity's coins
 type ComponentCoins struct {
 	Coins int
 }
 
 // ComponentDash indicates that an entity can dash
+//
+// Charge and MaxCharge count simulation ticks, SpeedMod is in pixels per second
 type ComponentDash struct {
 	Charge    int
 	MaxCharge int
@@ -60,6 +108,8 @@ type ComponentInvincible struct {
 }
 
 // ComponentMovement contains data about movement
+//
+// Speeds are in pixels per second. Move counts are in simulation ticks.
 type ComponentMovement struct {
 	LastDirection  Direction
 	Direction      Direction
@@ -86,11 +136,18 @@ type ComponentSpatial struct {
 }
 
 // ComponentAnimationData contains data about animating one sequence of sprites
+//
+// Frames holds the sprite of each frame and Durations how long each is shown.
+// Done is set when an AnimationOnce clip has finished.
 type ComponentAnimationData struct {
-	Frames         []int
-	Frame          int
-	FrameRate      int
-	FrameRateCount int
+	Frames     []int
+	Durations  []time.Duration
+	Mode       AnimationMode
+	Frame      int
+	Done       bool
+	OnComplete func()
+	elapsed    time.Duration
+	reverse    bool
 }
 
 // ComponentAnimationMap indexes ComponentAnimationData by use/context
@@ -99,9 +156,17 @@ type ComponentAnimationMap map[string]*ComponentAnimationData
 // ComponentAnimation contains everything necessary to animate basic characters
 type ComponentAnimation struct {
 	Map ComponentAnimationMap
+	// Key, if set, names the clip shown. Otherwise an entity that moves shows
+	// the clip named after the direction it faces, if it has one, and any
+	// other entity shows "default".
+	Key string
 }
 
 // ComponentTemporary is used to track when an entity should be removed
+//
+// SystemScheduler counts Expiration down once per tick and the entity expires at zero. A
+// negative Expiration does not count down; the entity expires when something
+// sets it to zero, such as the end of its animation clip.
 type ComponentTemporary struct {
 	Expiration   int
 	OnExpiration func()
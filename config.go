@@ -1,43 +1,10 @@
 package zelduh
 
-// TileSize defines the width and height of a tile
-const TileSize float64 = 48
+// TicksPerSecond defines how many times per second the simulation is updated
+const TicksPerSecond float64 = 60
 
-// TilemapFiles is a list of tilemap filenames
-var TilemapFiles = []string{
-	"overworldOpen",
-	"overworldOpenCircleOfTrees",
-	"overworldFourWallsDoorBottom",
-	"overworldFourWallsDoorLeftTop",
-	"overworldFourWallsDoorRightTop",
-	"overworldFourWallsDoorTopBottom",
-	"overworldFourWallsDoorRightTopBottom",
-	"overworldFourWallsDoorBottomRight",
-	"overworldFourWallsDoorTop",
-	"overworldFourWallsDoorRight",
-	"overworldFourWallsDoorLeft",
-	"overworldTreeClusterTopRight",
-	"overworldFourWallsClusterTrees",
-	"overworldFourWallsDoorsAllSides",
-	"rockPatternTest",
-	"rockPathOpenLeft",
-	"rockWithCaveEntrance",
-	"rockPathLeftRightEntrance",
-	"test",
-	"dungeonFourDoors",
-}
-
-// Overworld is a multi-dimensional array representing the overworld
-// Each room ID should be unique
-var Overworld = [][]RoomID{
-	{1, 10},
-	{2, 0, 0, 8},
-	{3, 5, 6, 7},
-	{9},
-	{11},
-}
-
-// NonObstacleSprites defines which sprites are not obstacles
+// NonObstacleSprites defines which sprites are not obstacles, for tiles whose
+// tileset does not set the collides property
 var NonObstacleSprites = map[int]bool{
 	8:   true,
 	9:   true,
@@ -52,74 +19,27 @@ var NonObstacleSprites = map[int]bool{
 	137: true,
 }
 
-// Map of RoomID to a Room configuration
-var RoomsMap = Rooms{
-	1: NewRoom("overworldFourWallsDoorBottomRight",
-		GetPreset("puzzleBox")(5, 5),
-		GetPreset("floorSwitch")(5, 6),
-		GetPreset("toggleObstacle")(10, 7),
-	),
-	2: NewRoom("overworldFourWallsDoorTopBottom",
-		GetPreset("skull")(5, 5),
-		GetPreset("skeleton")(11, 9),
-		GetPreset("spinner")(7, 9),
-		GetPreset("eyeburrower")(8, 9),
-	),
-	3: NewRoom("overworldFourWallsDoorRightTopBottom",
-		WarpStone(3, 7, 6, 5),
-	),
-	5: NewRoom("rockWithCaveEntrance",
-		EntityConfig{
-			Category:     CategoryWarp,
-			WarpToRoomID: 11,
-			W:            TileSize,
-			H:            TileSize,
-			X:            (TileSize * 7) + TileSize/2,
-			Y:            (TileSize * 9) + TileSize/2,
-			Hitbox: &HitboxConfig{
-				Radius: 30,
-			},
-		},
-		EntityConfig{
-			Category:     CategoryWarp,
-			WarpToRoomID: 11,
-			W:            TileSize,
-			H:            TileSize,
-			X:            (TileSize * 8) + TileSize/2,
-			Y:            (TileSize * 9) + TileSize/2,
-			Hitbox: &HitboxConfig{
-				Radius: 30,
-			},
+// RoomsMap is the rooms of the world, by ID; the game builds it from the
+// world file with World.BuildRooms
+var RoomsMap = Rooms{}
+
+// RoomEntityConfigs returns the entities of each room that are not placed in
+// the room's map, for tiles of the given size
+func RoomEntityConfigs(tileSize float64) map[RoomID][]EntityConfig {
+	return map[RoomID][]EntityConfig{
+		1: {
+			MustGetPreset("puzzleBox")(tileSize, 4, 3),
+			MustGetPreset("floorSwitch")(tileSize, 4, 4),
+			MustGetPreset("toggleObstacle")(tileSize, 9, 5),
 		},
-	),
-	6:  NewRoom("rockPathLeftRightEntrance"),
-	7:  NewRoom("overworldFourWallsDoorLeftTop"),
-	8:  NewRoom("overworldFourWallsDoorBottom"),
-	9:  NewRoom("overworldFourWallsDoorTop"),
-	10: NewRoom("overworldFourWallsDoorLeft"),
-	11: NewRoom("dungeonFourDoors",
-		// South door of cave - warp to cave entrance
-		EntityConfig{
-			Category:     CategoryWarp,
-			WarpToRoomID: 5,
-			W:            TileSize,
-			H:            TileSize,
-			X:            (TileSize * 6) + TileSize + (TileSize / 2.5),
-			Y:            (TileSize * 1) + TileSize + (TileSize / 2.5),
-			Hitbox: &HitboxConfig{
-				Radius: 15,
-			},
+		2: {
+			MustGetPreset("skull")(tileSize, 4, 3),
+			MustGetPreset("skeleton")(tileSize, 10, 7),
+			MustGetPreset("spinner")(tileSize, 6, 7),
+			MustGetPreset("eyeburrower")(tileSize, 7, 7),
 		},
-		EntityConfig{
-			Category:     CategoryWarp,
-			WarpToRoomID: 5,
-			W:            TileSize,
-			H:            TileSize,
-			X:            (TileSize * 7) + TileSize + (TileSize / 2.5),
-			Y:            (TileSize * 1) + TileSize + (TileSize / 2.5),
-			Hitbox: &HitboxConfig{
-				Radius: 15,
-			},
+		3: {
+			WarpStone(tileSize, 2, 5, 6, 5),
 		},
-	),
+	}
 }
@@ -3,12 +3,13 @@ package zelduh
 import (
 	"encoding/csv"
 	"io"
-	"log"
 	"strings"
 )
 
 // ParseCSV translates a CSV string into a multi-dimensional array of strings
-func ParseCSV(in string) [][]string {
+//
+// Errors give the line of the string the CSV is malformed on.
+func ParseCSV(in string) ([][]string, error) {
 	r := csv.NewReader(strings.NewReader(in))
 
 	records := [][]string{}
@@ -18,10 +19,10 @@ func ParseCSV(in string) [][]string {
 			break
 		}
 		if err != nil {
-			log.Fatal(err)
+			return nil, err
 		}
 
 		records = append(records, record)
 	}
-	return records
+	return records, nil
 }
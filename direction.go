@@ -1,7 +1,5 @@
 package zelduh
 
-import "math/rand"
-
 // Direction is the type of direction
 type Direction string
 
@@ -17,7 +15,7 @@ const (
 )
 
 // RandomDirection returns a random direction
-func RandomDirection(r *rand.Rand) Direction {
+func RandomDirection(r *Random) Direction {
 	i := r.Intn(4)
 	switch i {
 	case 0:
@@ -32,3 +30,19 @@ func RandomDirection(r *rand.Rand) Direction {
 		return DirectionUp
 	}
 }
+
+// Opposite returns the direction facing the other way
+func (d Direction) Opposite() Direction {
+	switch d {
+	case DirectionUp:
+		return DirectionDown
+	case DirectionRight:
+		return DirectionLeft
+	case DirectionDown:
+		return DirectionUp
+	case DirectionLeft:
+		return DirectionRight
+	default:
+		return d
+	}
+}
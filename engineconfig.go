@@ -0,0 +1,134 @@
+package zelduh
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/faiface/pixel"
+)
+
+// Config holds the engine settings that depend on the content and display,
+// rather than on the game rules
+type Config struct {
+	// TileSize is the width and height of a tile, in pixels
+	TileSize float64 `json:"tileSize"`
+	// MapWidthTiles and MapHeightTiles are the size of the map viewport, in
+	// tiles. Each room's size comes from its map; smaller rooms are centered
+	// in the viewport, and larger rooms scroll.
+	MapWidthTiles  int `json:"mapWidthTiles"`
+	MapHeightTiles int `json:"mapHeightTiles"`
+	// FrameRate is the number of ticks an animation frame is shown for, when
+	// its clip does not set a duration
+	FrameRate int `json:"frameRate"`
+	// SlideTicks is the number of ticks a slide from one room to the next takes
+	SlideTicks int `json:"slideTicks"`
+	// WindowWidth and WindowHeight are the size of the window, in pixels
+	WindowWidth  float64 `json:"windowWidth"`
+	WindowHeight float64 `json:"windowHeight"`
+	// PoolSizes is the most entities of each pooled preset, such as
+	// "explosion" or "coin", that can exist at once
+	PoolSizes map[string]int `json:"poolSizes"`
+}
+
+// DefaultConfig returns the settings the game was designed with
+func DefaultConfig() Config {
+	return Config{
+		TileSize:       48,
+		MapWidthTiles:  14,
+		MapHeightTiles: 12,
+		FrameRate:      5,
+		SlideTicks:     48,
+		WindowWidth:    800,
+		WindowHeight:   800,
+		PoolSizes: map[string]int{
+			"explosion": 8,
+			"coin":      16,
+		},
+	}
+}
+
+// PoolSize returns the pool size for the preset, or 0 if it has none
+func (c Config) PoolSize(preset string) int {
+	return c.PoolSizes[preset]
+}
+
+// LoadConfig reads a JSON config file
+//
+// Settings missing from the file keep their DefaultConfig value.
+func LoadConfig(path string) (Config, error) {
+	config := DefaultConfig()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return config, err
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return config, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := config.validate(); err != nil {
+		return config, fmt.Errorf("%s: %w", path, err)
+	}
+	return config, nil
+}
+
+func (c Config) validate() error {
+	if c.TileSize <= 0 {
+		return fmt.Errorf("tileSize must be positive, got %v", c.TileSize)
+	}
+	if c.MapWidthTiles <= 0 || c.MapHeightTiles <= 0 {
+		return fmt.Errorf("map must be at least one tile, got %dx%d", c.MapWidthTiles, c.MapHeightTiles)
+	}
+	if c.FrameRate <= 0 {
+		return fmt.Errorf("frameRate must be positive, got %d", c.FrameRate)
+	}
+	if c.SlideTicks <= 0 {
+		return fmt.Errorf("slideTicks must be positive, got %d", c.SlideTicks)
+	}
+	if c.WindowWidth <= 0 || c.WindowHeight <= 0 {
+		return fmt.Errorf("window size must be positive, got %vx%v", c.WindowWidth, c.WindowHeight)
+	}
+	for preset, size := range c.PoolSizes {
+		if size <= 0 {
+			return fmt.Errorf("poolSizes.%s must be positive, got %d", preset, size)
+		}
+	}
+	return nil
+}
+
+// WindowConfig returns the window rectangle
+func (c Config) WindowConfig() WindowConfig {
+	return WindowConfig{
+		Width:  c.WindowWidth,
+		Height: c.WindowHeight,
+	}
+}
+
+// MapConfig returns the map viewport, centered in the window
+func (c Config) MapConfig() MapConfig {
+	width := c.TileSize * float64(c.MapWidthTiles)
+	height := c.TileSize * float64(c.MapHeightTiles)
+	return MapConfig{
+		X:      (c.WindowWidth - width) / 2,
+		Y:      (c.WindowHeight - height) / 2,
+		Width:  width,
+		Height: height,
+	}
+}
+
+// MapBoundsConfig returns the edges of a room the size of the viewport, in room
+// coordinates; the game sets the collision bounds of each room from its map
+func (c Config) MapBoundsConfig() MapBoundsConfig {
+	mapConfig := c.MapConfig()
+	return MapBoundsConfig{
+		Width:  mapConfig.Width,
+		Height: mapConfig.Height,
+	}
+}
+
+// View returns the transform from room coordinates, where 0,0 is the bottom
+// left corner of the map, to screen coordinates
+func (c Config) View() pixel.Matrix {
+	mapConfig := c.MapConfig()
+	return pixel.IM.Moved(pixel.V(mapConfig.X, mapConfig.Y))
+}
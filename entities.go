@@ -15,16 +15,47 @@ type Entityer interface {
 // EntityCategory is used to group entities
 type EntityCategory uint
 
-// EntityID represents an entity ID
-type EntityID int
+// EntityID is a generational handle to an entity
+//
+// The low 32 bits are the index of the entity's slot in the SystemsManager and
+// the high 32 bits are the slot's generation. Destroying an entity frees the
+// slot for reuse under the next generation, so an ID kept after its entity was
+// destroyed never matches the slot's new occupant.
+type EntityID uint64
+
+func newEntityID(index, generation uint32) EntityID {
+	return EntityID(uint64(generation)<<32 | uint64(index))
+}
+
+// Index returns the slot index of the ID
+func (id EntityID) Index() uint32 {
+	return uint32(id)
+}
+
+// Generation returns the slot generation of the ID
+func (id EntityID) Generation() uint32 {
+	return uint32(id >> 32)
+}
 
 // Entity is used to represent each character and tangable "thing" in the game
+//
+// Entities belong to the current room and are destroyed when it is left,
+// unless they are Persistent, like the player and HUD.
+//
+// Entity rects are in room coordinates, where 0,0 is the bottom left corner of
+// the map, except for HUD entities, whose rects are in screen coordinates.
 type Entity struct {
-	id       EntityID
-	Category EntityCategory
+	id         EntityID
+	Category   EntityCategory
+	Persistent bool
+	HUD        bool
+	// Name, if set, is unique among the entities in the world
+	Name string
+	Tags []string
 	*ComponentInvincible
 	*ComponentAnimation
 	*ComponentAppearance
+	*ComponentAttachment
 	*ComponentCoins
 	*ComponentDash
 	*ComponentEnabled
@@ -43,12 +74,13 @@ func NewEntityByEntityIDMap() EntityByEntityID {
 }
 
 type Entities struct {
-	Player    Entity
-	Bomb      Entity
-	Explosion Entity
-	Sword     Entity
-	Arrow     Entity
-	Hearts    []Entity
+	Player     Entity
+	Bomb       Entity
+	Sword      Entity
+	Arrow      Entity
+	Hearts     []Entity
+	Explosions *EntityPool
+	Coins      *EntityPool
 }
 
 // ID returns the entity ID
@@ -56,6 +88,66 @@ func (e *Entity) ID() EntityID {
 	return e.id
 }
 
+// Components returns the set of components the entity has
+func (e *Entity) Components() ComponentType {
+	var c ComponentType
+	if e.ComponentAnimation != nil {
+		c |= ComponentTypeAnimation
+	}
+	if e.ComponentAppearance != nil {
+		c |= ComponentTypeAppearance
+	}
+	if e.ComponentAttachment != nil {
+		c |= ComponentTypeAttachment
+	}
+	if e.ComponentCoins != nil {
+		c |= ComponentTypeCoins
+	}
+	if e.ComponentDash != nil {
+		c |= ComponentTypeDash
+	}
+	if e.ComponentEnabled != nil {
+		c |= ComponentTypeEnabled
+	}
+	if e.ComponentHealth != nil {
+		c |= ComponentTypeHealth
+	}
+	if e.ComponentIgnore != nil {
+		c |= ComponentTypeIgnore
+	}
+	if e.ComponentInvincible != nil {
+		c |= ComponentTypeInvincible
+	}
+	if e.ComponentMovement != nil {
+		c |= ComponentTypeMovement
+	}
+	if e.ComponentSpatial != nil {
+		c |= ComponentTypeSpatial
+	}
+	if e.ComponentTemporary != nil {
+		c |= ComponentTypeTemporary
+	}
+	if e.ComponentToggler != nil {
+		c |= ComponentTypeToggler
+	}
+	return c
+}
+
+// HasTag returns true if the entity is tagged with tag
+func (e *Entity) HasTag(tag string) bool {
+	for _, t := range e.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// Has returns true if the entity has every component in components
+func (e *Entity) Has(components ComponentType) bool {
+	return e.Components()&components == components
+}
+
 // BuildEntitiesFromConfigs builds and returns a batch of entities
 func BuildEntitiesFromConfigs(newEntityID func() EntityID, frameRate int, configs ...EntityConfig) []Entity {
 	batch := []Entity{}
@@ -69,14 +161,19 @@ func BuildEntitiesFromConfigs(newEntityID func() EntityID, frameRate int, config
 // BuildEntityFromConfig builds an entity from a configuration
 func BuildEntityFromConfig(c EntityConfig, id EntityID, frameRate int) Entity {
 	entity := Entity{
-		id:       id,
-		Category: c.Category,
+		id:         id,
+		Category:   c.Category,
+		Persistent: c.Persistent,
+		HUD:        c.HUD,
+		Name:       c.Name,
+		Tags:       append([]string(nil), c.Tags...),
 		ComponentSpatial: &ComponentSpatial{
-			Width:  c.W,
-			Height: c.H,
-			Rect:   pixel.R(c.X, c.Y, c.X+c.W, c.Y+c.H),
-			Shape:  imdraw.New(nil),
-			HitBox: imdraw.New(nil),
+			Width:    c.W,
+			Height:   c.H,
+			PrevRect: pixel.R(c.X, c.Y, c.X+c.W, c.Y+c.H),
+			Rect:     pixel.R(c.X, c.Y, c.X+c.W, c.Y+c.H),
+			Shape:    imdraw.New(nil),
+			HitBox:   imdraw.New(nil),
 		},
 		ComponentIgnore: &ComponentIgnore{
 			Value: c.Ignore,
@@ -87,6 +184,10 @@ func BuildEntityFromConfig(c EntityConfig, id EntityID, frameRate int) Entity {
 		entity.ComponentTemporary = &ComponentTemporary{
 			Expiration: c.Expiration,
 		}
+	} else if c.ExpiresWithAnimation {
+		entity.ComponentTemporary = &ComponentTemporary{
+			Expiration: -1,
+		}
 	}
 
 	if c.Category == CategoryWarp {
@@ -150,14 +251,24 @@ func BuildEntityFromConfig(c EntityConfig, id EntityID, frameRate int) Entity {
 		}
 	}
 
+	if c.Attachment != nil {
+		entity.ComponentAttachment = &ComponentAttachment{
+			Offsets: c.Attachment.Offsets,
+		}
+	}
+
 	if c.Animation != nil {
 		entity.ComponentAnimation = &ComponentAnimation{
 			Map: ComponentAnimationMap{},
 		}
-		for key, val := range c.Animation {
-			entity.ComponentAnimation.Map[key] = &ComponentAnimationData{
-				Frames:    val,
-				FrameRate: frameRate,
+		for key, clip := range c.Animation {
+			entity.ComponentAnimation.Map[key] = newComponentAnimationData(clip, frameRate)
+		}
+		if temporary := entity.ComponentTemporary; c.ExpiresWithAnimation && temporary != nil {
+			if animData := entity.ComponentAnimation.Map["default"]; animData != nil {
+				animData.OnComplete = func() {
+					temporary.Expiration = 0
+				}
 			}
 		}
 	} else {
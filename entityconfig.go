@@ -1,11 +1,12 @@
 package zelduh
 
 import (
+	"github.com/faiface/pixel"
 	"github.com/faiface/pixel/imdraw"
 )
 
-// AnimationConfig is a map of animation types to sprite index lists
-type AnimationConfig map[string][]int
+// AnimationConfig is a map of animation types to animation clips
+type AnimationConfig map[string]AnimationClip
 
 // MovementConfig is used to configure an entity's Movement component
 type MovementConfig struct {
@@ -34,6 +35,13 @@ type DashConfig struct {
 	SpeedMod          float64
 }
 
+// AttachmentConfig is used to configure an entity's Attachment component
+//
+// The entity starts detached; ComponentAttachment.Attach sets its parent.
+type AttachmentConfig struct {
+	Offsets map[Direction]pixel.Vec
+}
+
 // EntityConfig is used to simplify building entities
 type EntityConfig struct {
 	Category                                                      EntityCategory
@@ -45,9 +53,106 @@ type EntityConfig struct {
 	Toggled                                                       bool
 	Health                                                        int
 	Expiration                                                    int
+	ExpiresWithAnimation                                          bool
 	Ignore                                                        bool
+	Persistent                                                    bool
+	HUD                                                           bool
+	Name                                                          string
+	Tags                                                          []string
 	Animation                                                     AnimationConfig
 	Hitbox                                                        *HitboxConfig
 	Dash                                                          *DashConfig
 	Movement                                                      *MovementConfig
+	Attachment                                                    *AttachmentConfig
+}
+
+// PresetOverride changes fields of an EntityConfig, such as one built by a preset
+//
+// Overrides replace, rather than modify, the sub configs they change, so that
+// configs built from the same preset never share changes.
+type PresetOverride func(c *EntityConfig)
+
+// With returns a copy of the config with the overrides applied, in order
+func (c EntityConfig) With(overrides ...PresetOverride) EntityConfig {
+	for _, override := range overrides {
+		override(&c)
+	}
+	return c
+}
+
+// WithAnimation sets the clip shown for the animation name
+func WithAnimation(name, clipKey string) PresetOverride {
+	return func(c *EntityConfig) {
+		animation := AnimationConfig{}
+		for k, v := range c.Animation {
+			animation[k] = v
+		}
+		animation[name] = GetAnimationClip(clipKey)
+		c.Animation = animation
+	}
+}
+
+// WithHealth sets the starting health
+func WithHealth(health int) PresetOverride {
+	return func(c *EntityConfig) {
+		c.Health = health
+	}
+}
+
+// WithSpeed sets the speed, and max speed, in pixels per second
+func WithSpeed(speed float64) PresetOverride {
+	return func(c *EntityConfig) {
+		movement := MovementConfig{}
+		if c.Movement != nil {
+			movement = *c.Movement
+		}
+		movement.Speed = speed
+		movement.MaxSpeed = speed
+		c.Movement = &movement
+	}
+}
+
+// WithPattern sets the movement pattern, such as "random" or "left-right"
+func WithPattern(pattern string) PresetOverride {
+	return func(c *EntityConfig) {
+		movement := MovementConfig{}
+		if c.Movement != nil {
+			movement = *c.Movement
+		}
+		movement.PatternName = pattern
+		c.Movement = &movement
+	}
+}
+
+// WithHitboxRadius sets the hitbox radius
+func WithHitboxRadius(radius float64) PresetOverride {
+	return func(c *EntityConfig) {
+		hitbox := HitboxConfig{}
+		if c.Hitbox != nil {
+			hitbox = *c.Hitbox
+		}
+		hitbox.Radius = radius
+		c.Hitbox = &hitbox
+	}
+}
+
+// WithWarpTo sets the room a warp leads to
+func WithWarpTo(roomID RoomID) PresetOverride {
+	return func(c *EntityConfig) {
+		c.WarpToRoomID = roomID
+	}
+}
+
+// WithName sets the entity's unique name
+func WithName(name string) PresetOverride {
+	return func(c *EntityConfig) {
+		c.Name = name
+	}
+}
+
+// WithTags adds tags to the entity
+func WithTags(tags ...string) PresetOverride {
+	return func(c *EntityConfig) {
+		c.Tags = append(append([]string(nil), c.Tags...), tags...)
+	}
 }
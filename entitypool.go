@@ -0,0 +1,115 @@
+package zelduh
+
+import "github.com/faiface/pixel"
+
+// PoolOverflow decides what an EntityPool does when it is asked to spawn while full
+type PoolOverflow int
+
+const (
+	// PoolOverflowDrop refuses the spawn, leaving the live entities alone
+	PoolOverflowDrop PoolOverflow = iota
+	// PoolOverflowRecycleOldest expires the oldest live entity, running its
+	// OnExpiration if it is temporary, and spawns in its place
+	PoolOverflowRecycleOldest
+)
+
+// EntityPool spawns transient entities, such as explosions and coin drops,
+// from a preset, and limits how many of them can exist at once
+//
+// Entities leave the pool when they are destroyed, whether they expire, are
+// picked up or are cleaned up with their room.
+type EntityPool struct {
+	SystemsManager *SystemsManager
+	Preset         string
+	Size           int
+	Overflow       PoolOverflow
+	Config         Config
+
+	build entityConfigPresetFn
+	live  []Entity
+}
+
+// NewEntityPool returns a new EntityPool for the preset
+//
+// The pool size is taken from config.PoolSizes, keyed by preset. It panics if
+// there is no such preset, so that a mistyped key fails at startup rather than
+// on the first spawn.
+func NewEntityPool(systemsManager *SystemsManager, preset string, overflow PoolOverflow, config Config) *EntityPool {
+	return &EntityPool{
+		SystemsManager: systemsManager,
+		Preset:         preset,
+		Size:           config.PoolSize(preset),
+		Overflow:       overflow,
+		Config:         config,
+		build:          MustGetPreset(preset),
+	}
+}
+
+// Live returns the number of entities spawned by the pool that still exist
+func (p *EntityPool) Live() int {
+	p.reclaim()
+	return len(p.live)
+}
+
+// Spawn builds an entity from the preset at v, in room coordinates, and queues
+// it to be added
+//
+// configure, if not nil, is called with the entity before it is queued. Spawn
+// returns false if the pool is full and its overflow is PoolOverflowDrop.
+func (p *EntityPool) Spawn(v pixel.Vec, configure func(entity *Entity)) (Entity, bool) {
+	p.reclaim()
+	if p.Size <= 0 {
+		return Entity{}, false
+	}
+	if len(p.live) >= p.Size {
+		if p.Overflow == PoolOverflowDrop {
+			return Entity{}, false
+		}
+		p.expire(p.live[0])
+		p.remove(0)
+	}
+
+	tileSize := p.Config.TileSize
+	config := p.build(tileSize, v.X/tileSize, v.Y/tileSize)
+	entity := BuildEntityFromConfig(config, p.SystemsManager.NewEntityID(), p.Config.FrameRate)
+	if configure != nil {
+		configure(&entity)
+	}
+	p.live = append(p.live, entity)
+	p.SystemsManager.Commands.Spawn(entity)
+	return entity, true
+}
+
+// expire queues the entity to be destroyed, running its OnExpiration first
+func (p *EntityPool) expire(entity Entity) {
+	if entity.ComponentTemporary != nil {
+		if entity.ComponentTemporary.OnExpiration != nil {
+			entity.ComponentTemporary.OnExpiration()
+		}
+		// Keep SystemScheduler from expiring it again before it is destroyed
+		entity.ComponentTemporary.OnExpiration = nil
+	}
+	p.SystemsManager.Commands.Destroy(entity.ID())
+}
+
+// remove forgets the live entity at index i, keeping the others in order
+func (p *EntityPool) remove(i int) {
+	n := len(p.live) - 1
+	copy(p.live[i:], p.live[i+1:])
+	p.live[n] = Entity{}
+	p.live = p.live[:n]
+}
+
+// reclaim forgets the entities that have been destroyed
+func (p *EntityPool) reclaim() {
+	live := p.live[:0]
+	for _, entity := range p.live {
+		if p.SystemsManager.Valid(entity.ID()) {
+			live = append(live, entity)
+		}
+	}
+	for i := len(live); i < len(p.live); i++ {
+		p.live[i] = Entity{}
+	}
+	p.live = live
+}
@@ -0,0 +1,101 @@
+package zelduh
+
+import (
+	"testing"
+
+	"github.com/faiface/pixel"
+)
+
+func newTestEntityPool(w *SystemsManager, overflow PoolOverflow, size int) *EntityPool {
+	config := DefaultConfig()
+	config.PoolSizes = map[string]int{"explosion": size}
+	return NewEntityPool(w, "explosion", overflow, config)
+}
+
+func TestEntityPoolDrop(t *testing.T) {
+	w := NewSystemsManager(1)
+	p := newTestEntityPool(&w, PoolOverflowDrop, 2)
+
+	for i := 0; i < 2; i++ {
+		if _, ok := p.Spawn(pixel.ZV, nil); !ok {
+			t.Fatalf("Spawn #%d = false below capacity", i)
+		}
+	}
+	if _, ok := p.Spawn(pixel.ZV, nil); ok {
+		t.Error("Spawn = true at capacity")
+	}
+	w.flush()
+	if got := w.Count(0, 0); got != 2 {
+		t.Errorf("%d entities spawned, want 2", got)
+	}
+	if got := p.Live(); got != 2 {
+		t.Errorf("Live = %d, want 2", got)
+	}
+}
+
+func TestEntityPoolRecycleOldest(t *testing.T) {
+	w := NewSystemsManager(1)
+	p := newTestEntityPool(&w, PoolOverflowRecycleOldest, 2)
+
+	expired := 0
+	oldest, _ := p.Spawn(pixel.ZV, func(entity *Entity) {
+		entity.ComponentTemporary.OnExpiration = func() { expired++ }
+	})
+	second, _ := p.Spawn(pixel.ZV, nil)
+	w.flush()
+
+	newest, ok := p.Spawn(pixel.ZV, nil)
+	if !ok {
+		t.Fatal("Spawn = false at capacity")
+	}
+	w.flush()
+
+	if expired != 1 {
+		t.Errorf("oldest OnExpiration ran %d times, want 1", expired)
+	}
+	if got, want := entityIDs(w.Query(0, 0)), []EntityID{second.ID(), newest.ID()}; !equalIDs(got, want) {
+		t.Errorf("entities = %v, want %v (oldest %v recycled)", got, want, oldest.ID())
+	}
+	if got := p.Live(); got != 2 {
+		t.Errorf("Live = %d, want 2", got)
+	}
+}
+
+func TestEntityPoolPrunesDestroyedEntities(t *testing.T) {
+	w := NewSystemsManager(1)
+	p := newTestEntityPool(&w, PoolOverflowDrop, 2)
+
+	picked, _ := p.Spawn(pixel.ZV, nil)
+	p.Spawn(pixel.ZV, nil)
+	w.flush()
+
+	// Destroyed outside the pool, like a coin that is picked up
+	w.DestroyEntity(picked.ID())
+	if got := p.Live(); got != 1 {
+		t.Errorf("Live = %d after one was destroyed, want 1", got)
+	}
+	if _, ok := p.Spawn(pixel.ZV, nil); !ok {
+		t.Error("Spawn = false after a live entity was destroyed")
+	}
+}
+
+func TestEntityPoolReusesLiveSlice(t *testing.T) {
+	w := NewSystemsManager(1)
+	p := newTestEntityPool(&w, PoolOverflowRecycleOldest, 2)
+
+	p.Spawn(pixel.ZV, nil)
+	p.Spawn(pixel.ZV, nil)
+	w.flush()
+	array := &p.live[0]
+
+	for i := 0; i < 100; i++ {
+		p.Spawn(pixel.ZV, nil)
+		w.flush()
+	}
+	if &p.live[0] != array {
+		t.Error("recycling reallocated the live slice")
+	}
+	if got := w.Count(0, 0); got != 2 {
+		t.Errorf("%d entities exist, want 2", got)
+	}
+}
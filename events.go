@@ -0,0 +1,144 @@
+package zelduh
+
+import "github.com/faiface/pixel"
+
+// EventType identifies a kind of Event
+type EventType string
+
+const (
+	// EventTypeCollision is published by SystemCollision for each collision it detects
+	EventTypeCollision EventType = "collision"
+	// EventTypeEnemyHit is published when an enemy takes damage and survives
+	EventTypeEnemyHit EventType = "enemyHit"
+	// EventTypeEnemyKilled is published when an enemy's health reaches zero
+	EventTypeEnemyKilled EventType = "enemyKilled"
+	// EventTypeCoinCollected is published when the player picks up a coin
+	EventTypeCoinCollected EventType = "coinCollected"
+	// EventTypePlayerDamaged is published when the player takes damage
+	EventTypePlayerDamaged EventType = "playerDamaged"
+	// EventTypeRoomEntered is published when the entities of a room have been added
+	EventTypeRoomEntered EventType = "roomEntered"
+)
+
+// Event is something that happened in the game
+type Event interface {
+	Type() EventType
+}
+
+// CollisionKind identifies the two kinds of entity involved in a collision
+type CollisionKind string
+
+const (
+	CollisionPlayerWithBounds           CollisionKind = "playerWithBounds"
+	CollisionPlayerWithCoin             CollisionKind = "playerWithCoin"
+	CollisionPlayerWithEnemy            CollisionKind = "playerWithEnemy"
+	CollisionSwordWithEnemy             CollisionKind = "swordWithEnemy"
+	CollisionArrowWithEnemy             CollisionKind = "arrowWithEnemy"
+	CollisionArrowWithObstacle          CollisionKind = "arrowWithObstacle"
+	CollisionPlayerWithObstacle         CollisionKind = "playerWithObstacle"
+	CollisionPlayerWithMoveableObstacle CollisionKind = "playerWithMoveableObstacle"
+	CollisionMoveableObstacleWithSwitch CollisionKind = "moveableObstacleWithSwitch"
+	CollisionMoveableObstacleNoSwitch   CollisionKind = "moveableObstacleNoSwitch"
+	CollisionEnemyWithObstacle          CollisionKind = "enemyWithObstacle"
+	CollisionPlayerWithSwitch           CollisionKind = "playerWithSwitch"
+	CollisionPlayerNoSwitch             CollisionKind = "playerNoSwitch"
+	CollisionPlayerWithWarp             CollisionKind = "playerWithWarp"
+)
+
+// CollisionEvent reports a collision between two entities, or between an entity and the map bounds
+type CollisionEvent struct {
+	Kind     CollisionKind
+	EntityID EntityID
+	OtherID  EntityID
+	Bound    Bound
+}
+
+// Type returns EventTypeCollision
+func (e CollisionEvent) Type() EventType { return EventTypeCollision }
+
+// EnemyHitEvent reports that an enemy took damage and survived
+type EnemyHitEvent struct {
+	EnemyID EntityID
+	Damage  int
+}
+
+// Type returns EventTypeEnemyHit
+func (e EnemyHitEvent) Type() EventType { return EventTypeEnemyHit }
+
+// EnemyKilledEvent reports that an enemy died, and where
+type EnemyKilledEvent struct {
+	EnemyID EntityID
+	Rect    pixel.Rect
+}
+
+// Type returns EventTypeEnemyKilled
+func (e EnemyKilledEvent) Type() EventType { return EventTypeEnemyKilled }
+
+// CoinCollectedEvent reports that the player picked up a coin
+type CoinCollectedEvent struct {
+	CoinID EntityID
+	Total  int
+}
+
+// Type returns EventTypeCoinCollected
+func (e CoinCollectedEvent) Type() EventType { return EventTypeCoinCollected }
+
+// PlayerDamagedEvent reports that the player took damage
+type PlayerDamagedEvent struct {
+	SourceID EntityID
+	Health   int
+}
+
+// Type returns EventTypePlayerDamaged
+func (e PlayerDamagedEvent) Type() EventType { return EventTypePlayerDamaged }
+
+// RoomEnteredEvent reports that the player entered a room
+type RoomEnteredEvent struct {
+	RoomID RoomID
+}
+
+// Type returns EventTypeRoomEntered
+func (e RoomEnteredEvent) Type() EventType { return EventTypeRoomEntered }
+
+// EventHandler reacts to an Event
+type EventHandler func(Event)
+
+// EventBus queues published events and delivers them to subscribers
+//
+// Events are not delivered when they are published, only when Dispatch is
+// called, so publishers never run subscriber code in the middle of their own work.
+type EventBus struct {
+	handlers map[EventType][]EventHandler
+	queue    []Event
+}
+
+// NewEventBus returns a new EventBus
+func NewEventBus() EventBus {
+	return EventBus{
+		handlers: map[EventType][]EventHandler{},
+	}
+}
+
+// Subscribe registers the handler for all events of the given type
+func (b *EventBus) Subscribe(eventType EventType, handler EventHandler) {
+	b.handlers[eventType] = append(b.handlers[eventType], handler)
+}
+
+// Publish queues the event for the next Dispatch
+func (b *EventBus) Publish(event Event) {
+	b.queue = append(b.queue, event)
+}
+
+// Dispatch delivers queued events in the order they were published
+//
+// Handlers run in the order they subscribed. Events published by handlers are
+// delivered in the same Dispatch, after the events already queued.
+func (b *EventBus) Dispatch() {
+	for i := 0; i < len(b.queue); i++ {
+		event := b.queue[i]
+		for _, handler := range b.handlers[event.Type()] {
+			handler(event)
+		}
+	}
+	b.queue = b.queue[:0]
+}
@@ -0,0 +1,60 @@
+package zelduh
+
+import (
+	"reflect"
+	"testing"
+)
+
+// systemFunc is a System that calls itself on Update
+type systemFunc func(dt float64)
+
+func (f systemFunc) Update(dt float64) { f(dt) }
+
+func TestEventBusDispatchOrder(t *testing.T) {
+	b := NewEventBus()
+	got := []string{}
+	b.Subscribe(EventTypeEnemyHit, func(e Event) {
+		got = append(got, "hit a")
+		b.Publish(RoomEnteredEvent{})
+	})
+	b.Subscribe(EventTypeEnemyHit, func(e Event) { got = append(got, "hit b") })
+	b.Subscribe(EventTypeRoomEntered, func(e Event) { got = append(got, "room") })
+	b.Subscribe(EventTypeCoinCollected, func(e Event) { got = append(got, "coin") })
+
+	b.Publish(EnemyHitEvent{})
+	b.Publish(CoinCollectedEvent{})
+	if len(got) != 0 {
+		t.Fatalf("handlers ran before Dispatch: %v", got)
+	}
+
+	b.Dispatch()
+	want := []string{"hit a", "hit b", "coin", "room"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("handled %v, want %v", got, want)
+	}
+
+	b.Dispatch()
+	if len(got) != len(want) {
+		t.Errorf("events were delivered twice: %v", got)
+	}
+}
+
+func TestEventsDispatchAfterEachSystem(t *testing.T) {
+	w := NewSystemsManager(1)
+	got := []string{}
+	w.Events.Subscribe(EventTypeEnemyHit, func(e Event) { got = append(got, "handler") })
+	w.AddSystem("first", SystemPhaseSimulation, systemFunc(func(dt float64) {
+		got = append(got, "first")
+		w.Events.Publish(EnemyHitEvent{})
+	}))
+	w.AddSystem("second", SystemPhaseSimulation, systemFunc(func(dt float64) {
+		got = append(got, "second")
+	}))
+
+	w.tick(testDt)
+
+	want := []string{"first", "handler", "second"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ran %v, want %v", got, want)
+	}
+}
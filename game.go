@@ -0,0 +1,136 @@
+package zelduh
+
+// GameConfig is what NewGame builds a game from
+type GameConfig struct {
+	Config Config
+	// Seed is the seed for all game randomness; see Random for what it replays
+	Seed           int64
+	UI             UI
+	LocaleMessages LocaleMessagesMap
+	// WorldPath is the world file, and TilemapDir the directory of its maps
+	WorldPath       string
+	TilemapDir      string
+	SpritesheetPath string
+}
+
+// NewGame loads the world and wires up the systems, entities and collision
+// handler of a game, which starts in StateLoading
+//
+// Assets load in the background while StateLoading shows their progress. Only
+// the first room's map is loaded up front; the others load as the player gets
+// near them.
+func NewGame(cfg GameConfig) (*GameStateManager, error) {
+	config := cfg.Config
+	frameRate := config.FrameRate
+	tileSize := config.TileSize
+
+	world, err := LoadWorld(cfg.WorldPath, cfg.TilemapDir)
+	if err != nil {
+		return nil, err
+	}
+	RoomsMap = world.BuildRooms(RoomEntityConfigs(tileSize))
+
+	systemsManager := NewSystemsManager(cfg.Seed)
+
+	roomData := NewRoomData()
+	roomData.CurrentRoomID = world.Start
+
+	assets := NewAssetLoader()
+	spritesheet := LoadSpritesheet(assets, cfg.SpritesheetPath, tileSize)
+	maps := NewMapStore(cfg.TilemapDir, tileSize)
+	maps.SetRooms(RoomsMap)
+	maps.Preload(assets, RoomsMap[roomData.CurrentRoomID].MapName())
+
+	roomTransitionManager := NewRoomTransitionManager(config)
+
+	entities := Entities{
+		Player: BuildEntityFromConfig(MustGetPreset("player")(tileSize, 5, 4), systemsManager.NewEntityID(), frameRate),
+		Bomb:   BuildEntityFromConfig(MustGetPreset("bomb")(tileSize, 0, 0), systemsManager.NewEntityID(), frameRate),
+		Sword:  BuildEntityFromConfig(MustGetPreset("sword")(tileSize, 0, 0), systemsManager.NewEntityID(), frameRate),
+		Arrow:  BuildEntityFromConfig(MustGetPreset("arrow")(tileSize, 0, 0), systemsManager.NewEntityID(), frameRate),
+		Hearts: BuildEntitiesFromConfigs(
+			systemsManager.NewEntityID,
+			frameRate,
+			MustGetPreset("heart")(tileSize, 1.5, 14),
+			MustGetPreset("heart")(tileSize, 2.15, 14),
+			MustGetPreset("heart")(tileSize, 2.80, 14),
+		),
+		// A new explosion replaces the oldest; a coin that does not fit is not dropped
+		Explosions: NewEntityPool(&systemsManager, "explosion", PoolOverflowRecycleOldest, config),
+		Coins:      NewEntityPool(&systemsManager, "coin", PoolOverflowDrop, config),
+	}
+
+	schedulerSystem := &SystemScheduler{
+		SystemsManager: &systemsManager,
+	}
+
+	collisionSystem := NewSystemCollision(
+		config.MapBoundsConfig(),
+		&systemsManager,
+	)
+
+	roomWarps := map[EntityID]EntityConfig{}
+
+	collisionHandler := NewCollisionHandler(
+		&roomTransitionManager,
+		&systemsManager,
+		roomWarps,
+		entities,
+		&roomData,
+	)
+	collisionHandler.Subscribe(&systemsManager.Events)
+
+	systemsManager.AddSystem(SystemNameInput, SystemPhaseInput, &SystemInput{
+		Win:            cfg.UI.Window,
+		SystemsManager: &systemsManager,
+	})
+	systemsManager.AddSystem(SystemNameScheduler, SystemPhaseSimulation, schedulerSystem)
+	systemsManager.AddSystem(SystemNameHealth, SystemPhaseSimulation, &SystemHealth{
+		SystemsManager: &systemsManager,
+	})
+	systemsManager.AddSystem(SystemNameSpatial, SystemPhaseSimulation, &SystemSpatial{
+		SystemsManager: &systemsManager,
+		TileSize:       tileSize,
+	})
+	systemsManager.AddSystem(SystemNameCollision, SystemPhaseCollision, &collisionSystem)
+	systemsManager.AddSystem(SystemNameRender, SystemPhaseRender, &SystemRender{
+		Win:            cfg.UI.Window,
+		Spritesheet:    spritesheet,
+		SystemsManager: &systemsManager,
+		View:           config.View(),
+	})
+
+	// The sword and arrow are carried by the player
+	entities.Sword.ComponentAttachment.Attach(entities.Player.ID())
+	entities.Arrow.ComponentAttachment.Attach(entities.Player.ID())
+
+	systemsManager.AddEntities(
+		entities.Player,
+		entities.Sword,
+		entities.Arrow,
+		entities.Bomb,
+	)
+
+	// The HUD is added once, and kept across rooms
+	AddUIHearts(&systemsManager, entities.Hearts, entities.Player.ComponentHealth.Total)
+	AddUICoin(&systemsManager, config)
+
+	gameStateManager := NewGameStateManager(
+		&roomTransitionManager,
+		&systemsManager,
+		cfg.UI,
+		cfg.LocaleMessages,
+		spritesheet,
+		NewEntityByEntityIDMap(),
+		maps,
+		roomWarps,
+		entities,
+		&roomData,
+		config,
+	)
+	gameStateManager.Assets = assets
+	collisionHandler.GameStateManager = &gameStateManager
+	gameStateManager.Rooms.OnUnload(schedulerSystem.CancelRoom)
+
+	return &gameStateManager, nil
+}
@@ -1,115 +1,289 @@
 package zelduh
 
-import "github.com/faiface/pixel"
+import (
+	"fmt"
+
+	"github.com/faiface/pixel"
+)
 
 // State is a type of game state
 type State string
 
 const (
+	StateLoading       State = "loading"
 	StateStart         State = "start"
 	StateGame          State = "game"
 	StatePause         State = "pause"
 	StateOver          State = "over"
 	StateMapTransition State = "mapTransition"
+	StateError         State = "error"
+)
+
+// GameState is one screen of the game, such as the start screen or the game itself
+type GameState interface {
+	// Enter is called when the state is added to the stack
+	//
+	// Enter and Exit may Push, Pop, Change or Fail. Those changes are applied
+	// after the changes already queued, before any state is updated.
+	Enter(g *GameStateManager)
+	// Exit is called when the state is removed from the stack
+	Exit(g *GameStateManager)
+	// Update runs the state for one frame that took dt seconds
+	//
+	// Only the state at the top of the stack is updated.
+	Update(g *GameStateManager, dt float64)
+	// Draw draws the state
+	//
+	// Every state on the stack is drawn, bottom first, so a state pushed over
+	// another one draws over a frozen copy of it.
+	Draw(g *GameStateManager)
+}
+
+type stateChangeOp int
+
+const (
+	stateChangePush stateChangeOp = iota
+	stateChangePop
+	stateChangeChange
 )
 
+type stateChange struct {
+	op   stateChangeOp
+	name State
+}
+
+// GameStateManager runs a stack of game states once per frame
 type GameStateManager struct {
 	RoomTransitionManager *RoomTransitionManager
 	SystemsManager        *SystemsManager
-	GameStateManager      *GameStateManager
 	UI                    UI
 	LocaleMessages        LocaleMessagesMap
-	CollisionSystem       *SystemCollision
-	InputSystem           *SystemInput
-	Spritesheet           map[int]*pixel.Sprite
+	Spritesheet           *Spritesheet
 	EntitiesMap           EntityByEntityID
-	AllMapDrawData        map[string]MapData
+	Maps                  *MapStore
 	RoomWarps             map[EntityID]EntityConfig
 	Entities              Entities
-	CurrentState          State
 	RoomData              *RoomData
+	Config                Config
 	MapConfig             MapConfig
 	WindowConfig          WindowConfig
-	FrameRate             int
+	Camera                *Camera
+	Rooms                 RoomLifecycle
+	// Assets, if set, is waited for in StateLoading
+	Assets *AssetLoader
+	// Err is the error that stopped the game, shown in StateError
+	Err error
+
+	states       map[State]GameState
+	stateSystems map[State][]string
+	stack        []State
+	pending      []stateChange
 }
 
+// NewGameStateManager returns a new GameStateManager with the built in states
+// registered, that starts in StateLoading
 func NewGameStateManager(
 	roomTransitionManager *RoomTransitionManager,
 	systemsManager *SystemsManager,
 	ui UI,
 	localeMessages LocaleMessagesMap,
-	collisionSystem *SystemCollision,
-	inputSystem *SystemInput,
-	spritesheet map[int]*pixel.Sprite,
+	spritesheet *Spritesheet,
 	entitiesMap EntityByEntityID,
-	allMapDrawData map[string]MapData,
+	maps *MapStore,
 	roomWarps map[EntityID]EntityConfig,
 	entities Entities,
 	roomData *RoomData,
-	mapConfig MapConfig,
-	windowConfig WindowConfig,
-	frameRate int,
+	config Config,
 ) GameStateManager {
 	return GameStateManager{
 		RoomTransitionManager: roomTransitionManager,
 		SystemsManager:        systemsManager,
 		UI:                    ui,
 		LocaleMessages:        localeMessages,
-		CollisionSystem:       collisionSystem,
-		InputSystem:           inputSystem,
 		Spritesheet:           spritesheet,
 		EntitiesMap:           entitiesMap,
-		AllMapDrawData:        allMapDrawData,
+		Maps:                  maps,
 		RoomWarps:             roomWarps,
 		Entities:              entities,
-		CurrentState:          StateStart,
 		RoomData:              roomData,
-		MapConfig:             mapConfig,
-		WindowConfig:          windowConfig,
-		FrameRate:             frameRate,
+		Config:                config,
+		MapConfig:             config.MapConfig(),
+		WindowConfig:          config.WindowConfig(),
+		Camera:                NewCamera(config.MapConfig()),
+		Rooms: NewRoomLifecycle(
+			systemsManager,
+			entitiesMap,
+			roomWarps,
+			maps,
+			config,
+		),
+		states: map[State]GameState{
+			StateLoading:       &GameStateLoading{},
+			StateStart:         &GameStateStart{},
+			StateGame:          &GameStateGame{},
+			StatePause:         &GameStatePause{},
+			StateOver:          &GameStateOver{},
+			StateMapTransition: &GameStateMapTransition{},
+			StateError:         &GameStateError{},
+		},
+		stateSystems: map[State][]string{
+			StateLoading:       {},
+			StateError:         {},
+			StatePause:         {SystemNameRender},
+			StateMapTransition: {SystemNameScheduler, SystemNameSpatial, SystemNameRender},
+		},
+		pending: []stateChange{{stateChangeChange, StateLoading}},
+	}
+}
+
+// Register adds a state that can then be pushed or changed to by name,
+// replacing any state already registered with that name
+func (g *GameStateManager) Register(name State, state GameState) {
+	g.states[name] = state
+}
+
+// SetStateSystems sets the systems that run while the named state is at the
+// top of the stack
+//
+// Every system runs while a state with no systems set is at the top of the stack.
+func (g *GameStateManager) SetStateSystems(name State, systems ...string) {
+	g.stateSystems[name] = systems
+}
+
+// Push adds the named state to the top of the stack
+func (g *GameStateManager) Push(name State) {
+	g.mustBeRegistered(name)
+	g.pending = append(g.pending, stateChange{stateChangePush, name})
+}
+
+// Pop removes the state at the top of the stack
+func (g *GameStateManager) Pop() {
+	g.pending = append(g.pending, stateChange{op: stateChangePop})
+}
+
+// Change replaces every state on the stack with the named state
+func (g *GameStateManager) Change(name State) {
+	g.mustBeRegistered(name)
+	g.pending = append(g.pending, stateChange{stateChangeChange, name})
+}
+
+// Current returns the name of the state at the top of the stack
+func (g *GameStateManager) Current() State {
+	if len(g.stack) == 0 {
+		return ""
+	}
+	return g.stack[len(g.stack)-1]
+}
+
+// Update applies state changes requested since the last frame, then updates
+// the state at the top of the stack and draws the whole stack
+func (g *GameStateManager) Update(dt float64) {
+	g.applyStateChanges()
+
+	if len(g.stack) == 0 {
+		return
+	}
+	g.states[g.Current()].Update(g, dt)
+
+	for _, name := range g.stack {
+		g.states[name].Draw(g)
+	}
+}
+
+// applyStateChanges applies the queued state changes in order, including
+// those queued by Enter and Exit while applying them
+func (g *GameStateManager) applyStateChanges() {
+	for len(g.pending) > 0 {
+		pending := g.pending
+		g.pending = nil
+		for _, change := range pending {
+			switch change.op {
+			case stateChangePush:
+				g.push(change.name)
+			case stateChangePop:
+				g.pop()
+			case stateChangeChange:
+				for len(g.stack) > 0 {
+					g.pop()
+				}
+				g.push(change.name)
+			}
+		}
+	}
+
+	if systems, ok := g.stateSystems[g.Current()]; ok {
+		g.SystemsManager.EnableOnlySystems(systems...)
+	} else {
+		g.SystemsManager.EnableAllSystems()
+	}
+}
+
+func (g *GameStateManager) push(name State) {
+	g.stack = append(g.stack, name)
+	g.states[name].Enter(g)
+}
+
+func (g *GameStateManager) pop() {
+	if len(g.stack) == 0 {
+		return
+	}
+	name := g.Current()
+	g.stack = g.stack[:len(g.stack)-1]
+	g.states[name].Exit(g)
+}
+
+// inputSystem returns the system registered as SystemNameInput, if it is a
+// SystemInput
+func (g *GameStateManager) inputSystem() (*SystemInput, bool) {
+	sys, _ := g.SystemsManager.System(SystemNameInput)
+	input, ok := sys.(*SystemInput)
+	return input, ok
+}
+
+// renderSystem returns the system registered as SystemNameRender, if it is a
+// SystemRender
+func (g *GameStateManager) renderSystem() (*SystemRender, bool) {
+	sys, _ := g.SystemsManager.System(SystemNameRender)
+	render, ok := sys.(*SystemRender)
+	return render, ok
+}
+
+// Fail stops the game, and shows err in StateError
+func (g *GameStateManager) Fail(err error) {
+	g.Err = err
+	g.Change(StateError)
+}
+
+// currentMap returns the map of the current room
+func (g *GameStateManager) currentMap() (MapData, error) {
+	room, err := RoomsMap.room(g.RoomData.CurrentRoomID)
+	if err != nil {
+		return MapData{}, err
+	}
+	return g.Maps.Get(room.MapName())
+}
+
+// playerCenter returns the center of the player, where it is drawn this frame
+func (g *GameStateManager) playerCenter() pixel.Vec {
+	spatial := g.Entities.Player.ComponentSpatial
+	min := pixel.Lerp(spatial.PrevRect.Min, spatial.Rect.Min, g.SystemsManager.Timestep.Alpha())
+	return min.Add(pixel.V(spatial.Width/2, spatial.Height/2))
+}
+
+// drawRoom draws the systems, with the room's entities moved to the screen by
+// view and hidden outside of screen
+func (g *GameStateManager) drawRoom(view pixel.Matrix, screen MapConfig) {
+	if render, ok := g.renderSystem(); ok {
+		render.View = view
+		render.Mask = func() {
+			DrawMask(g.UI.Window, g.WindowConfig, screen)
+		}
 	}
+	g.SystemsManager.Draw()
 }
 
-func (g *GameStateManager) Update() {
-	switch g.CurrentState {
-	case StateStart:
-		GameStateStart(g.UI, g.LocaleMessages, g.GameStateManager, g.MapConfig)
-	case StateGame:
-		GameStateGame(
-			g.UI,
-			g.MapConfig,
-			g.WindowConfig,
-			g.Spritesheet,
-			g.RoomData,
-			g.Entities,
-			g.RoomWarps,
-			g.EntitiesMap,
-			g.AllMapDrawData,
-			g.InputSystem,
-			RoomsMap,
-			g.SystemsManager,
-			g.GameStateManager,
-			g.FrameRate,
-		)
-	case StatePause:
-		GameStatePause(g.UI, g.LocaleMessages, g.GameStateManager, g.MapConfig)
-	case StateOver:
-		GameStateOver(g.UI, g.LocaleMessages, g.GameStateManager, g.MapConfig)
-	case StateMapTransition:
-		GameStateMapTransition(
-			g.UI,
-			g.Spritesheet,
-			g.Entities,
-			g.AllMapDrawData,
-			g.InputSystem,
-			g.SystemsManager,
-			RoomsMap,
-			g.CollisionSystem,
-			g.GameStateManager,
-			g.RoomData,
-			g.RoomTransitionManager,
-			g.WindowConfig,
-			g.MapConfig,
-		)
+func (g *GameStateManager) mustBeRegistered(name State) {
+	if _, ok := g.states[name]; !ok {
+		panic(fmt.Sprintf("game state %q is not registered", name))
 	}
 }
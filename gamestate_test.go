@@ -0,0 +1,191 @@
+package zelduh
+
+import (
+	"reflect"
+	"testing"
+)
+
+// recordingState is a GameState that logs the calls made to it
+type recordingState struct {
+	name    State
+	log     *[]string
+	onEnter func(g *GameStateManager)
+	onExit  func(g *GameStateManager)
+}
+
+func (s *recordingState) Enter(g *GameStateManager) {
+	*s.log = append(*s.log, "enter "+string(s.name))
+	if s.onEnter != nil {
+		s.onEnter(g)
+	}
+}
+
+func (s *recordingState) Exit(g *GameStateManager) {
+	*s.log = append(*s.log, "exit "+string(s.name))
+	if s.onExit != nil {
+		s.onExit(g)
+	}
+}
+
+func (s *recordingState) Update(g *GameStateManager, dt float64) {
+	*s.log = append(*s.log, "update "+string(s.name))
+}
+
+func (s *recordingState) Draw(g *GameStateManager) {
+	*s.log = append(*s.log, "draw "+string(s.name))
+}
+
+// newTestGameStateManager returns a GameStateManager with recording states a,
+// b and c registered, and no state changes queued
+func newTestGameStateManager(log *[]string) (*GameStateManager, map[State]*recordingState) {
+	systemsManager := NewSystemsManager(1)
+	roomTransitionManager := NewRoomTransitionManager(DefaultConfig())
+	g := NewGameStateManager(
+		&roomTransitionManager,
+		&systemsManager,
+		UI{},
+		LocaleMessagesMap{},
+		nil,
+		NewEntityByEntityIDMap(),
+		nil,
+		map[EntityID]EntityConfig{},
+		Entities{},
+		&RoomData{},
+		DefaultConfig(),
+	)
+	g.pending = nil
+	states := map[State]*recordingState{}
+	for _, name := range []State{"a", "b", "c"} {
+		states[name] = &recordingState{name: name, log: log}
+		g.Register(name, states[name])
+	}
+	return &g, states
+}
+
+func TestGameStateManagerStack(t *testing.T) {
+	tests := []struct {
+		name      string
+		setup     func(states map[State]*recordingState)
+		changes   func(g *GameStateManager)
+		wantStack []State
+		wantLog   []string
+	}{
+		{
+			name:      "push",
+			changes:   func(g *GameStateManager) { g.Push("a"); g.Push("b") },
+			wantStack: []State{"a", "b"},
+			wantLog:   []string{"enter a", "enter b"},
+		},
+		{
+			name:      "pop",
+			changes:   func(g *GameStateManager) { g.Push("a"); g.Push("b"); g.Pop() },
+			wantStack: []State{"a"},
+			wantLog:   []string{"enter a", "enter b", "exit b"},
+		},
+		{
+			name:      "pop empty",
+			changes:   func(g *GameStateManager) { g.Pop() },
+			wantStack: nil,
+			wantLog:   nil,
+		},
+		{
+			name:      "change exits top first",
+			changes:   func(g *GameStateManager) { g.Push("a"); g.Push("b"); g.Change("c") },
+			wantStack: []State{"c"},
+			wantLog:   []string{"enter a", "enter b", "exit b", "exit a", "enter c"},
+		},
+		{
+			name: "enter changes state",
+			setup: func(states map[State]*recordingState) {
+				states["b"].onEnter = func(g *GameStateManager) { g.Change("c") }
+			},
+			changes:   func(g *GameStateManager) { g.Push("a"); g.Push("b") },
+			wantStack: []State{"c"},
+			wantLog:   []string{"enter a", "enter b", "exit b", "exit a", "enter c"},
+		},
+		{
+			name: "enter change applied after queued changes",
+			setup: func(states map[State]*recordingState) {
+				states["a"].onEnter = func(g *GameStateManager) { g.Push("c") }
+			},
+			changes:   func(g *GameStateManager) { g.Push("a"); g.Push("b") },
+			wantStack: []State{"a", "b", "c"},
+			wantLog:   []string{"enter a", "enter b", "enter c"},
+		},
+		{
+			name: "exit pushes state",
+			setup: func(states map[State]*recordingState) {
+				states["b"].onExit = func(g *GameStateManager) { g.Push("c") }
+			},
+			changes:   func(g *GameStateManager) { g.Push("a"); g.Push("b"); g.Pop() },
+			wantStack: []State{"a", "c"},
+			wantLog:   []string{"enter a", "enter b", "exit b", "enter c"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var log []string
+			g, states := newTestGameStateManager(&log)
+			if tt.setup != nil {
+				tt.setup(states)
+			}
+			tt.changes(g)
+			g.applyStateChanges()
+
+			if got := append([]State{}, g.stack...); !reflect.DeepEqual(got, append([]State{}, tt.wantStack...)) {
+				t.Errorf("stack = %v, want %v", g.stack, tt.wantStack)
+			}
+			if !reflect.DeepEqual(log, tt.wantLog) {
+				t.Errorf("calls = %v, want %v", log, tt.wantLog)
+			}
+			if len(g.pending) != 0 {
+				t.Errorf("%d changes left pending", len(g.pending))
+			}
+		})
+	}
+}
+
+func TestGameStateManagerUpdate(t *testing.T) {
+	var log []string
+	g, _ := newTestGameStateManager(&log)
+	g.Push("a")
+	g.Push("b")
+
+	g.Update(testDt)
+
+	want := []string{"enter a", "enter b", "update b", "draw a", "draw b"}
+	if !reflect.DeepEqual(log, want) {
+		t.Errorf("calls = %v, want %v", log, want)
+	}
+	if g.Current() != "b" {
+		t.Errorf("Current = %s, want b", g.Current())
+	}
+}
+
+func TestGameStateManagerWithoutRenderSystem(t *testing.T) {
+	var log []string
+	g, _ := newTestGameStateManager(&log)
+
+	if _, ok := g.inputSystem(); ok {
+		t.Error("inputSystem found, but none is registered")
+	}
+	g.drawRoom(g.Config.View(), g.MapConfig)
+
+	g.SystemsManager.AddSystem(SystemNameInput, SystemPhaseInput, &SystemInput{SystemsManager: g.SystemsManager})
+	// A system registered under the render name that is not a SystemRender
+	g.SystemsManager.AddSystem(SystemNameRender, SystemPhaseRender, systemFunc(func(dt float64) {}))
+
+	if _, ok := g.inputSystem(); !ok {
+		t.Error("inputSystem not found")
+	}
+	if _, ok := g.renderSystem(); ok {
+		t.Error("renderSystem found, but it is not a SystemRender")
+	}
+	g.drawRoom(g.Config.View(), g.MapConfig)
+
+	// The spatial system is not registered
+	g.SystemsManager.EnableOnlySystems(SystemNameSpatial, SystemNameInput)
+	if !g.SystemsManager.SystemEnabled(SystemNameInput) || g.SystemsManager.SystemEnabled(SystemNameRender) {
+		t.Error("EnableOnlySystems did not enable only the registered systems named")
+	}
+}
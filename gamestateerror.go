@@ -0,0 +1,30 @@
+package zelduh
+
+import (
+	"fmt"
+
+	"golang.org/x/image/colornames"
+)
+
+// GameStateError handles functionality for the game "error" state
+//
+// It shows g.Err, such as a map that failed to load, and stays; the game
+// cannot go on without the content that failed.
+type GameStateError struct{}
+
+// Enter is a no-op
+func (s *GameStateError) Enter(g *GameStateManager) {}
+
+// Exit is a no-op
+func (s *GameStateError) Exit(g *GameStateManager) {}
+
+// Update is a no-op
+func (s *GameStateError) Update(g *GameStateManager, dt float64) {}
+
+// Draw draws the error screen
+func (s *GameStateError) Draw(g *GameStateManager) {
+	message := fmt.Sprintf("%s\n%v", g.LocaleMessages["errorScreenMessage"], g.Err)
+	g.UI.Window.Clear(colornames.Darkgray)
+	DrawMapBackground(g.UI.Window, g.MapConfig, colornames.Black)
+	DrawCenterText(g.UI.Window, g.UI.Text, message, colornames.White)
+}
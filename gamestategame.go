@@ -1,73 +1,63 @@
 package zelduh
 
 import (
-	"github.com/faiface/pixel"
 	"github.com/faiface/pixel/pixelgl"
 	"golang.org/x/image/colornames"
 )
 
-func GameStateGame(
-	ui UI,
-	mapConfig MapConfig,
-	windowConfig WindowConfig,
-	spritesheet map[int]*pixel.Sprite,
-	roomData *RoomData,
-	entities Entities,
-	roomWarps map[EntityID]EntityConfig,
-	entitiesMap EntityByEntityID,
-	allMapDrawData map[string]MapData,
-	inputSystem *SystemInput,
-	roomsMap Rooms,
-	systemsManager *SystemsManager,
-	gameStateManager *GameStateManager,
-	frameRate int,
-) {
-	inputSystem.EnablePlayer()
+// GameStateGame handles functionality for the game "game" state
+type GameStateGame struct{}
 
-	ui.Window.Clear(colornames.Darkgray)
-	DrawMapBackground(ui.Window, mapConfig, colornames.White)
+// Enter gives control to the player
+func (s *GameStateGame) Enter(g *GameStateManager) {
+	if input, ok := g.inputSystem(); ok {
+		input.EnablePlayer()
+	}
+}
+
+// Exit is a no-op
+func (s *GameStateGame) Exit(g *GameStateManager) {}
 
-	DrawMapBackgroundImage(
-		ui.Window,
-		spritesheet,
-		allMapDrawData,
-		roomsMap[roomData.CurrentRoomID].MapName(),
-		0, 0,
-		mapConfig,
-	)
+// Update loads the current room if needed and runs the systems
+func (s *GameStateGame) Update(g *GameStateManager, dt float64) {
+	systemsManager := g.SystemsManager
 
 	if systemsManager.GetShouldAddEntities() {
 		systemsManager.SetShouldAddEntities(false)
-		AddUIHearts(systemsManager, entities.Hearts, entities.Player.ComponentHealth.Total)
-
-		AddUICoin(systemsManager, frameRate)
-
-		// Draw obstacles on appropriate map tiles
-		obstacles := DrawObstaclesPerMapTiles(systemsManager, roomsMap, allMapDrawData, roomData.CurrentRoomID, 0, 0, mapConfig, frameRate)
-		systemsManager.AddEntities(obstacles...)
-
-		// Iterate through all entity configurations and build entities and add to systems
-		for _, c := range roomsMap[roomData.CurrentRoomID].(*Room).EntityConfigs {
-			entity := BuildEntityFromConfig(c, systemsManager.NewEntityID(), frameRate)
-			entitiesMap[entity.ID()] = entity
-			systemsManager.AddEntity(entity)
-
-			switch c.Category {
-			case CategoryWarp:
-				roomWarps[entity.ID()] = c
-			}
+		if err := g.Rooms.Load(g.RoomData.CurrentRoomID); err != nil {
+			g.Fail(err)
+			return
 		}
 	}
 
-	DrawMask(ui.Window, windowConfig, mapConfig)
+	systemsManager.Update(dt)
 
-	systemsManager.Update()
+	if g.UI.Window.JustPressed(pixelgl.KeyP) {
+		g.Push(StatePause)
+	}
 
-	if ui.Window.JustPressed(pixelgl.KeyP) {
-		gameStateManager.CurrentState = StatePause
+	if g.UI.Window.JustPressed(pixelgl.KeyX) {
+		g.Change(StateOver)
 	}
+}
 
-	if ui.Window.JustPressed(pixelgl.KeyX) {
-		gameStateManager.CurrentState = StateOver
+// Draw draws the current room and its entities, with the camera following the player
+func (s *GameStateGame) Draw(g *GameStateManager) {
+	mapData, err := g.currentMap()
+	if err != nil {
+		// Update fails the game when the room cannot be loaded
+		return
 	}
+
+	ui := g.UI
+	camera := g.Camera
+	camera.SetRoom(mapData.Bounds)
+	camera.Follow(g.playerCenter())
+	screen := camera.Screen()
+
+	ui.Window.Clear(colornames.Darkgray)
+	DrawMapBackground(ui.Window, screen, colornames.White)
+	DrawMapBackgroundImage(ui.Window, g.Spritesheet, mapData, camera.View())
+
+	g.drawRoom(camera.View(), screen)
 }
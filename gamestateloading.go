@@ -0,0 +1,35 @@
+package zelduh
+
+// GameStateLoading handles functionality for the game "loading" state
+//
+// It shows the progress of g.Assets, and moves on to StateStart once every
+// asset has loaded. If an asset fails to load, the game fails with its error.
+type GameStateLoading struct{}
+
+// Enter is a no-op
+func (s *GameStateLoading) Enter(g *GameStateManager) {}
+
+// Exit is a no-op
+func (s *GameStateLoading) Exit(g *GameStateManager) {}
+
+// Update moves on to the start screen once loading is done
+func (s *GameStateLoading) Update(g *GameStateManager, dt float64) {
+	if g.Assets == nil {
+		g.Change(StateStart)
+		return
+	}
+	if err := g.Assets.Err(); err != nil {
+		g.Fail(err)
+	} else if g.Assets.Done() {
+		g.Change(StateStart)
+	}
+}
+
+// Draw draws the loading progress
+func (s *GameStateLoading) Draw(g *GameStateManager) {
+	if g.Assets == nil {
+		return
+	}
+	done, total := g.Assets.Progress()
+	DrawScreenLoading(g.UI.Window, g.UI.Text, g.LocaleMessages["loadingScreenMessage"], done, total, g.MapConfig)
+}
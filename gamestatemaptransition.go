@@ -1,91 +1,112 @@
 package zelduh
 
 import (
+	"math"
+
 	"github.com/faiface/pixel"
 	"golang.org/x/image/colornames"
 )
 
-func GameStateMapTransition(
-	ui UI,
-	spritesheet map[int]*pixel.Sprite,
-	entities Entities,
-	allMapDrawData map[string]MapData,
-	inputSystem *SystemInput,
-	systemsManager *SystemsManager,
-	roomsMap Rooms,
-	collisionSystem *SystemCollision,
-	gameStateManager *GameStateManager,
-	roomData *RoomData,
-	roomTransitionManager *RoomTransitionManager,
-	windowConfig WindowConfig,
-	mapConfig MapConfig,
-) {
-	inputSystem.DisablePlayer()
-	if roomTransitionManager.Style() == TransitionSlide && roomTransitionManager.Timer() > 0 {
-		roomTransitionManager.DecrementTimer()
-		ui.Window.Clear(colornames.Darkgray)
-		DrawMapBackground(ui.Window, mapConfig, colornames.White)
+// GameStateMapTransition handles functionality for the game "mapTransition" state
+//
+// from shows the room being left, as it was last drawn, and to shows the room
+// a slide leads to, as it will be shown once the player enters it.
+type GameStateMapTransition struct {
+	resp     transitionRoomResponse
+	from, to Camera
+}
+
+// Enter takes control away from the player, and unloads the room being left
+func (s *GameStateMapTransition) Enter(g *GameStateManager) {
+	if input, ok := g.inputSystem(); ok {
+		input.DisablePlayer()
+	}
+	g.Rooms.Unload()
+
+	s.from = *g.Camera
+	s.to = s.from
+	if g.RoomTransitionManager.Style() != TransitionSlide {
+		return
+	}
+	side := g.RoomTransitionManager.Side()
+	current, err := RoomsMap.room(g.RoomData.CurrentRoomID)
+	if err != nil {
+		g.Fail(err)
+		return
+	}
+	nextRoom, ok := RoomsMap[current.ConnectedRooms().Side(side)]
+	if !ok {
+		return
+	}
+	mapData, err := g.Maps.Get(nextRoom.MapName())
+	if err != nil {
+		g.Fail(err)
+		return
+	}
+	s.to.SetRoom(mapData.Bounds)
+	s.to.Follow(entryPoint(g.playerCenter(), side, mapData.Bounds))
+}
+
+// Exit is a no-op
+func (s *GameStateMapTransition) Exit(g *GameStateManager) {}
 
-		collisionSystem.RemoveAll(CategoryObstacle)
-		systemsManager.RemoveAllEnemies()
-		systemsManager.RemoveAllCollisionSwitches()
-		systemsManager.RemoveAllMoveableObstacles()
-		systemsManager.RemoveAllEntities()
+// Update advances the transition, and switches to the next room when it is done
+func (s *GameStateMapTransition) Update(g *GameStateManager, dt float64) {
+	systemsManager := g.SystemsManager
+	roomData := g.RoomData
+	roomTransitionManager := g.RoomTransitionManager
 
-		currentRoomID := roomData.CurrentRoomID
+	if roomTransitionManager.Style() == TransitionSlide && roomTransitionManager.Timer() > 0 {
+		// The transition timer counts simulation ticks, so the slide takes the
+		// same time at any frame rate
+		ticks := systemsManager.Update(dt)
+		steps := 0
+		for ; steps < ticks && roomTransitionManager.Timer() > 0; steps++ {
+			roomTransitionManager.DecrementTimer()
+		}
 
-		connectedRooms := roomsMap[currentRoomID].ConnectedRooms()
+		current, err := RoomsMap.room(roomData.CurrentRoomID)
+		if err != nil {
+			g.Fail(err)
+			return
+		}
 
-		transitionRoomResp := calculateTransitionSlide(
+		s.resp = calculateTransitionSlide(
 			roomTransitionManager,
-			*connectedRooms,
+			*current.ConnectedRooms(),
 			roomData.CurrentRoomID,
-			mapConfig,
+			&s.from,
+			&s.to,
 		)
 
-		roomData.NextRoomID = transitionRoomResp.nextRoomID
+		roomData.NextRoomID = s.resp.nextRoomID
 
-		DrawMapBackgroundImage(
-			ui.Window,
-			spritesheet,
-			allMapDrawData,
-			roomsMap[roomData.CurrentRoomID].MapName(),
-			transitionRoomResp.modX,
-			transitionRoomResp.modY,
-			mapConfig,
-		)
-		DrawMapBackgroundImage(
-			ui.Window,
-			spritesheet,
-			allMapDrawData,
-			roomsMap[roomData.NextRoomID].MapName(),
-			transitionRoomResp.modXNext,
-			transitionRoomResp.modYNext,
-			mapConfig,
+		// Move player with map transition, once per tick
+		playerSpatial := g.Entities.Player.ComponentSpatial
+		v := pixel.V(
+			s.resp.playerModX*float64(steps),
+			s.resp.playerModY*float64(steps),
 		)
-		DrawMask(ui.Window, windowConfig, mapConfig)
-
-		// Move player with map transition
-		entities.Player.ComponentSpatial.Rect = pixel.R(
-			entities.Player.ComponentSpatial.Rect.Min.X+transitionRoomResp.playerModX,
-			entities.Player.ComponentSpatial.Rect.Min.Y+transitionRoomResp.playerModY,
-			entities.Player.ComponentSpatial.Rect.Min.X+transitionRoomResp.playerModX+TileSize,
-			entities.Player.ComponentSpatial.Rect.Min.Y+transitionRoomResp.playerModY+TileSize,
-		)
-
-		systemsManager.Update()
+		playerSpatial.PrevRect = playerSpatial.PrevRect.Moved(v)
+		playerSpatial.Rect = playerSpatial.Rect.Moved(v)
 	} else if roomTransitionManager.Style() == TransitionWarp && roomTransitionManager.Timer() > 0 {
 		roomTransitionManager.DecrementTimer()
-		ui.Window.Clear(colornames.Darkgray)
-		DrawMapBackground(ui.Window, mapConfig, colornames.White)
-
-		collisionSystem.RemoveAll(CategoryObstacle)
-		systemsManager.RemoveAllEnemies()
-		systemsManager.RemoveAllCollisionSwitches()
-		systemsManager.RemoveAllMoveableObstacles()
-		systemsManager.RemoveAllEntities()
 	} else {
-		gameStateManager.CurrentState = StateGame
+		if nextRoom, ok := RoomsMap[roomData.NextRoomID]; ok {
+			to, err := g.Maps.Get(nextRoom.MapName())
+			if err != nil {
+				g.Fail(err)
+				return
+			}
+			// The player slid along with the rooms, so it is where the next
+			// room's camera shows it once the slide is done
+			var offset pixel.Vec
+			if roomTransitionManager.Style() == TransitionSlide {
+				offset = s.from.Offset().Sub(s.to.Offset())
+			}
+			placePlayer(g.Entities.Player.ComponentSpatial, offset, to.Bounds)
+		}
+		g.Change(StateGame)
 		if roomData.NextRoomID != 0 {
 			roomData.CurrentRoomID = roomData.NextRoomID
 		}
@@ -93,53 +114,129 @@ func GameStateMapTransition(
 	}
 }
 
+// Draw draws the current room sliding out and the next one sliding in
+func (s *GameStateMapTransition) Draw(g *GameStateManager) {
+	ui := g.UI
+	screen := mapConfigUnion(s.from.Screen(), s.to.Screen())
+	ui.Window.Clear(colornames.Darkgray)
+	DrawMapBackground(ui.Window, screen, colornames.White)
+
+	if g.RoomTransitionManager.Style() != TransitionSlide {
+		return
+	}
+
+	// A map that failed to load is not drawn; Update fails the game instead
+	if mapData, err := g.currentMap(); err == nil {
+		DrawMapBackgroundImage(
+			ui.Window,
+			g.Spritesheet,
+			mapData,
+			s.from.View().Moved(pixel.V(s.resp.modX, s.resp.modY)),
+		)
+	}
+	if nextRoom, ok := RoomsMap[g.RoomData.NextRoomID]; ok {
+		if mapData, err := g.Maps.Get(nextRoom.MapName()); err == nil {
+			DrawMapBackgroundImage(
+				ui.Window,
+				g.Spritesheet,
+				mapData,
+				s.to.View().Moved(pixel.V(s.resp.modXNext, s.resp.modYNext)),
+			)
+		}
+	}
+
+	g.drawRoom(s.from.View(), screen)
+}
+
+// entryPoint returns where a player centered at v, leaving a room by side,
+// enters the next room, whose bounds are to
+func entryPoint(v pixel.Vec, side Bound, to pixel.Rect) pixel.Vec {
+	switch side {
+	case BoundBottom:
+		v.Y = to.Max.Y
+	case BoundTop:
+		v.Y = to.Min.Y
+	case BoundLeft:
+		v.X = to.Max.X
+	case BoundRight:
+		v.X = to.Min.X
+	}
+	return v
+}
+
+// placePlayer moves the player, at the end of a transition, into the next
+// room, whose bounds are to
+//
+// offset is added to the player's position to get its position in the next
+// room. The player is then kept inside the next room, clear of its edges, so
+// that it does not slide straight out again.
+func placePlayer(spatial *ComponentSpatial, offset pixel.Vec, to pixel.Rect) {
+	rect := spatial.Rect.Moved(offset)
+	min := pixel.V(
+		math.Max(to.Min.X+1, math.Min(rect.Min.X, to.Max.X-rect.W()-1)),
+		math.Max(to.Min.Y+1, math.Min(rect.Min.Y, to.Max.Y-rect.H()-1)),
+	)
+	v := offset.Add(min.Sub(rect.Min))
+	spatial.PrevRect = spatial.PrevRect.Moved(v)
+	spatial.Rect = spatial.Rect.Moved(v)
+}
+
 type transitionRoomResponse struct {
 	nextRoomID                                             RoomID
 	modX, modY, modXNext, modYNext, playerModX, playerModY float64
 }
 
+// calculateTransitionSlide returns how far the rooms have slid, and how far to
+// move the player this tick
+//
+// fromCamera and toCamera show the room being left and the next room, so that rooms of
+// different sizes slide until their edges meet on screen. The player moves
+// along with the rooms; see placePlayer.
 func calculateTransitionSlide(
 	roomTransitionManager *RoomTransitionManager,
 	connectedRooms ConnectedRooms,
 	currentRoomID RoomID,
-	mapConfig MapConfig,
+	fromCamera, toCamera *Camera,
 ) transitionRoomResponse {
 
+	from, to := fromCamera.Screen(), toCamera.Screen()
+
 	var nextRoomID RoomID
-	inc := (roomTransitionManager.Start() - float64(roomTransitionManager.Timer()))
-	incY := inc * (mapConfig.Height / TileSize)
-	incX := inc * (mapConfig.Width / TileSize)
+	start := roomTransitionManager.Start()
+	inc := (start - float64(roomTransitionManager.Timer()))
 	modY := 0.0
 	modYNext := 0.0
 	modX := 0.0
 	modXNext := 0.0
 	playerModX := 0.0
 	playerModY := 0.0
-	playerIncY := ((mapConfig.Height / TileSize) - 1) + 7
-	playerIncX := ((mapConfig.Width / TileSize) - 1) + 7
 
 	side := roomTransitionManager.Side()
 
 	if side == BoundBottom && connectedRooms.Bottom != 0 {
-		modY = incY
-		modYNext = incY - mapConfig.Height
+		distance := to.Y + to.Height - from.Y
+		modY = inc * (distance / start)
+		modYNext = modY - distance
 		nextRoomID = connectedRooms.Bottom
-		playerModY += playerIncY
+		playerModY = distance / start
 	} else if side == BoundTop && connectedRooms.Top != 0 {
-		modY = -incY
-		modYNext = -incY + mapConfig.Height
+		distance := from.Y + from.Height - to.Y
+		modY = -inc * (distance / start)
+		modYNext = modY + distance
 		nextRoomID = connectedRooms.Top
-		playerModY -= playerIncY
+		playerModY = -distance / start
 	} else if side == BoundLeft && connectedRooms.Left != 0 {
-		modX = incX
-		modXNext = incX - mapConfig.Width
+		distance := to.X + to.Width - from.X
+		modX = inc * (distance / start)
+		modXNext = modX - distance
 		nextRoomID = connectedRooms.Left
-		playerModX += playerIncX
+		playerModX = distance / start
 	} else if side == BoundRight && connectedRooms.Right != 0 {
-		modX = -incX
-		modXNext = -incX + mapConfig.Width
+		distance := from.X + from.Width - to.X
+		modX = -inc * (distance / start)
+		modXNext = modX + distance
 		nextRoomID = connectedRooms.Right
-		playerModX -= playerIncX
+		playerModX = -distance / start
 	} else {
 		nextRoomID = 0
 	}
@@ -0,0 +1,121 @@
+package zelduh
+
+import (
+	"testing"
+
+	"github.com/faiface/pixel"
+)
+
+// testSpatial returns a spatial component for a 48x48 entity at x, y
+func testSpatial(x, y float64) *ComponentSpatial {
+	rect := pixel.R(x, y, x+48, y+48)
+	return &ComponentSpatial{Width: 48, Height: 48, Rect: rect, PrevRect: rect}
+}
+
+func TestPlacePlayer(t *testing.T) {
+	room := pixel.R(0, 0, 1344, 1152)
+
+	tests := []struct {
+		name   string
+		x, y   float64
+		offset pixel.Vec
+		want   pixel.Rect
+	}{
+		{"warp inside the room", 300, 400, pixel.ZV, pixel.R(300, 400, 348, 448)},
+		{"warp past the edges", -20, 1200, pixel.ZV, pixel.R(1, 1103, 49, 1151)},
+		{"moved by the offset", 300, 400, pixel.V(100, -200), pixel.R(400, 200, 448, 248)},
+		{"moved past an edge", 300, 400, pixel.V(0, 800), pixel.R(300, 1103, 348, 1151)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spatial := testSpatial(tt.x, tt.y)
+			placePlayer(spatial, tt.offset, room)
+			if spatial.Rect != tt.want {
+				t.Errorf("Rect = %v, want %v", spatial.Rect, tt.want)
+			}
+			if spatial.PrevRect != tt.want {
+				t.Errorf("PrevRect = %v, want %v", spatial.PrevRect, tt.want)
+			}
+		})
+	}
+}
+
+// slideTestPlayer slides the player out of a room by side, as
+// GameStateMapTransition does, and returns where it is placed in the next room
+func slideTestPlayer(t *testing.T, side Bound, from, to pixel.Rect, spatial *ComponentSpatial) {
+	t.Helper()
+	config := DefaultConfig()
+	center := spatial.Rect.Center()
+
+	fromCamera := NewCamera(config.MapConfig())
+	fromCamera.SetRoom(from)
+	fromCamera.Follow(center)
+	toCamera := *fromCamera
+	toCamera.SetRoom(to)
+	toCamera.Follow(entryPoint(center, side, to))
+
+	connected := ConnectedRooms{}
+	switch side {
+	case BoundTop:
+		connected.Top = 2
+	case BoundRight:
+		connected.Right = 2
+	case BoundBottom:
+		connected.Bottom = 2
+	case BoundLeft:
+		connected.Left = 2
+	}
+
+	rtm := NewRoomTransitionManager(config)
+	rtm.SetSlideStart(side)
+	start := spatial.Rect.Min
+	var resp transitionRoomResponse
+	for rtm.Timer() > 0 {
+		rtm.DecrementTimer()
+		resp = calculateTransitionSlide(&rtm, connected, 1, fromCamera, &toCamera)
+		v := pixel.V(resp.playerModX, resp.playerModY)
+		spatial.Rect = spatial.Rect.Moved(v)
+		spatial.PrevRect = spatial.PrevRect.Moved(v)
+	}
+
+	// The player moves along with the room being left
+	if moved, slid := spatial.Rect.Min.Sub(start), pixel.V(resp.modX, resp.modY); moved != slid {
+		t.Errorf("player moved %v, the room slid %v", moved, slid)
+	}
+	if resp.modXNext != 0 || resp.modYNext != 0 {
+		t.Errorf("next room is %v from its place at the end of the slide", pixel.V(resp.modXNext, resp.modYNext))
+	}
+
+	placePlayer(spatial, fromCamera.Offset().Sub(toCamera.Offset()), to)
+}
+
+func TestSlidePlacesPlayer(t *testing.T) {
+	viewport := pixel.R(0, 0, 672, 576)
+	small := pixel.R(0, 0, 480, 384)
+	large := pixel.R(0, 0, 1344, 1152)
+
+	tests := []struct {
+		name     string
+		side     Bound
+		from, to pixel.Rect
+		x, y     float64
+		want     pixel.Rect
+	}{
+		{"down between rooms of the viewport's size", BoundBottom, viewport, viewport, 300, -10, pixel.R(300, 527, 348, 575)},
+		{"up between rooms of the viewport's size", BoundTop, viewport, viewport, 300, 540, pixel.R(300, 1, 348, 49)},
+		{"left between rooms of the viewport's size", BoundLeft, viewport, viewport, -10, 200, pixel.R(623, 200, 671, 248)},
+		{"down into a larger room", BoundBottom, viewport, large, 300, -10, pixel.R(300, 1103, 348, 1151)},
+		// The player keeps its place on screen along the side it crosses
+		{"right out of a scrolled room into a smaller one", BoundRight, large, small, 1300, 700, pixel.R(1, 168, 49, 216)},
+		{"up out of a smaller room into a larger one", BoundTop, small, large, 200, 350, pixel.R(296, 1, 344, 49)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spatial := testSpatial(tt.x, tt.y)
+			slideTestPlayer(t, tt.side, tt.from, tt.to, spatial)
+			if spatial.Rect != tt.want {
+				t.Errorf("Rect = %v, want %v", spatial.Rect, tt.want)
+			}
+		})
+	}
+}
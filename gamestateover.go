@@ -5,12 +5,25 @@ import (
 	"golang.org/x/image/colornames"
 )
 
-func GameStateOver(ui UI, currLocaleMsgs LocaleMessagesMap, gameStateManager *GameStateManager, mapConfig MapConfig) {
-	ui.Window.Clear(colornames.Darkgray)
-	DrawMapBackground(ui.Window, mapConfig, colornames.Black)
-	DrawCenterText(ui.Window, ui.Text, currLocaleMsgs["gameOverScreenMessage"], colornames.White)
+// GameStateOver handles functionality for the game "over" state
+type GameStateOver struct{}
 
-	if ui.Window.JustPressed(pixelgl.KeyEnter) {
-		gameStateManager.CurrentState = StateStart
+// Enter is a no-op
+func (s *GameStateOver) Enter(g *GameStateManager) {}
+
+// Exit is a no-op
+func (s *GameStateOver) Exit(g *GameStateManager) {}
+
+// Update returns to the start screen when enter is pressed
+func (s *GameStateOver) Update(g *GameStateManager, dt float64) {
+	if g.UI.Window.JustPressed(pixelgl.KeyEnter) {
+		g.Change(StateStart)
 	}
 }
+
+// Draw draws the game over screen
+func (s *GameStateOver) Draw(g *GameStateManager) {
+	g.UI.Window.Clear(colornames.Darkgray)
+	DrawMapBackground(g.UI.Window, g.MapConfig, colornames.Black)
+	DrawCenterText(g.UI.Window, g.UI.Text, g.LocaleMessages["gameOverScreenMessage"], colornames.White)
+}
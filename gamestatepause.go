@@ -1,19 +1,34 @@
 package zelduh
 
 import (
+	"github.com/faiface/pixel"
 	"github.com/faiface/pixel/pixelgl"
 	"golang.org/x/image/colornames"
 )
 
-func GameStatePause(ui UI, currLocaleMsgs LocaleMessagesMap, gameStateManager *GameStateManager, mapConfig MapConfig) {
-	ui.Window.Clear(colornames.Darkgray)
-	DrawMapBackground(ui.Window, mapConfig, colornames.White)
-	DrawCenterText(ui.Window, ui.Text, currLocaleMsgs["pauseScreenMessage"], colornames.Black)
+// GameStatePause handles functionality for the game "pause" state
+//
+// It is pushed over StateGame, which stays frozen underneath it.
+type GameStatePause struct{}
 
-	if ui.Window.JustPressed(pixelgl.KeyP) {
-		gameStateManager.CurrentState = StateGame
+// Enter is a no-op
+func (s *GameStatePause) Enter(g *GameStateManager) {}
+
+// Exit is a no-op
+func (s *GameStatePause) Exit(g *GameStateManager) {}
+
+// Update resumes the game, or quits to the start screen
+func (s *GameStatePause) Update(g *GameStateManager, dt float64) {
+	if g.UI.Window.JustPressed(pixelgl.KeyP) {
+		g.Pop()
 	}
-	if ui.Window.JustPressed(pixelgl.KeyEscape) {
-		gameStateManager.CurrentState = StateStart
+	if g.UI.Window.JustPressed(pixelgl.KeyEscape) {
+		g.Change(StateStart)
 	}
 }
+
+// Draw fades out the game underneath and draws the pause message over it
+func (s *GameStatePause) Draw(g *GameStateManager) {
+	DrawMapBackground(g.UI.Window, g.MapConfig, pixel.Alpha(0.6))
+	DrawCenterText(g.UI.Window, g.UI.Text, g.LocaleMessages["pauseScreenMessage"], colornames.Black)
+}
@@ -5,10 +5,22 @@ import (
 )
 
 // GameStateStart handles functionality for the game "start" state
-func GameStateStart(ui UI, currLocaleMsgs LocaleMessagesMap, gameStateManager *GameStateManager, mapConfig MapConfig) {
-	DrawScreenStart(ui.Window, ui.Text, currLocaleMsgs, mapConfig)
+type GameStateStart struct{}
 
-	if ui.Window.JustPressed(pixelgl.KeyEnter) {
-		gameStateManager.CurrentState = StateGame
+// Enter is a no-op
+func (s *GameStateStart) Enter(g *GameStateManager) {}
+
+// Exit is a no-op
+func (s *GameStateStart) Exit(g *GameStateManager) {}
+
+// Update starts the game when enter is pressed
+func (s *GameStateStart) Update(g *GameStateManager, dt float64) {
+	if g.UI.Window.JustPressed(pixelgl.KeyEnter) {
+		g.Change(StateGame)
 	}
 }
+
+// Draw draws the start screen
+func (s *GameStateStart) Draw(g *GameStateManager) {
+	DrawScreenStart(g.UI.Window, g.UI.Text, g.LocaleMessages, g.MapConfig)
+}
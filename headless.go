@@ -0,0 +1,113 @@
+package zelduh
+
+import (
+	"image/color"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/pixelgl"
+)
+
+// HeadlessWindow is a Window that does not need a display
+//
+// Drawing is counted but discarded, and input is simulated with Press and Release.
+type HeadlessWindow struct {
+	bounds    pixel.Rect
+	next      map[pixelgl.Button]bool
+	current   map[pixelgl.Button]bool
+	previous  map[pixelgl.Button]bool
+	closed    bool
+	DrawCalls int
+}
+
+// NewHeadlessWindow returns a new HeadlessWindow with the given bounds
+func NewHeadlessWindow(bounds pixel.Rect) *HeadlessWindow {
+	return &HeadlessWindow{
+		bounds:   bounds,
+		next:     map[pixelgl.Button]bool{},
+		current:  map[pixelgl.Button]bool{},
+		previous: map[pixelgl.Button]bool{},
+	}
+}
+
+// Press holds the button down, starting with the next call to Update
+func (w *HeadlessWindow) Press(button pixelgl.Button) {
+	w.next[button] = true
+}
+
+// Release lets the button go, starting with the next call to Update
+func (w *HeadlessWindow) Release(button pixelgl.Button) {
+	delete(w.next, button)
+}
+
+// Pressed returns true if the button is currently held down
+func (w *HeadlessWindow) Pressed(button pixelgl.Button) bool {
+	return w.current[button]
+}
+
+// JustPressed returns true if the button was pressed since the previous call to Update
+func (w *HeadlessWindow) JustPressed(button pixelgl.Button) bool {
+	return w.current[button] && !w.previous[button]
+}
+
+// Update advances simulated input by one frame
+func (w *HeadlessWindow) Update() {
+	w.previous = w.current
+	w.current = map[pixelgl.Button]bool{}
+	for button, pressed := range w.next {
+		w.current[button] = pressed
+	}
+}
+
+// SetClosed sets the value returned by Closed
+func (w *HeadlessWindow) SetClosed(closed bool) {
+	w.closed = closed
+}
+
+// Closed returns true once SetClosed(true) has been called
+func (w *HeadlessWindow) Closed() bool {
+	return w.closed
+}
+
+// Bounds returns the bounds of the window
+func (w *HeadlessWindow) Bounds() pixel.Rect {
+	return w.bounds
+}
+
+// Clear is a no-op
+func (w *HeadlessWindow) Clear(c color.Color) {}
+
+// MakeTriangles returns triangles that are counted, not drawn, by this window
+func (w *HeadlessWindow) MakeTriangles(t pixel.Triangles) pixel.TargetTriangles {
+	data := pixel.MakeTrianglesData(t.Len())
+	data.Update(t)
+	return &headlessTriangles{
+		TrianglesData: data,
+		window:        w,
+	}
+}
+
+// MakePicture returns a picture that is counted, not drawn, by this window
+func (w *HeadlessWindow) MakePicture(p pixel.Picture) pixel.TargetPicture {
+	return &headlessPicture{
+		Picture: p,
+		window:  w,
+	}
+}
+
+type headlessTriangles struct {
+	*pixel.TrianglesData
+	window *HeadlessWindow
+}
+
+func (t *headlessTriangles) Draw() {
+	t.window.DrawCalls++
+}
+
+type headlessPicture struct {
+	pixel.Picture
+	window *HeadlessWindow
+}
+
+func (p *headlessPicture) Draw(t pixel.TargetTriangles) {
+	p.window.DrawCalls++
+}
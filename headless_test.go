@@ -0,0 +1,250 @@
+package zelduh
+
+import (
+	_ "image/png"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/faiface/pixel/pixelgl"
+)
+
+const testDt = 1.0 / TicksPerSecond
+
+// testGame is a game built by NewGame, as cmd/zelduh builds it, on a HeadlessWindow
+type testGame struct {
+	manager  *GameStateManager
+	systems  *SystemsManager
+	window   *HeadlessWindow
+	entities Entities
+	roomData *RoomData
+}
+
+// newTestGame builds a game from the assets directory, in StateLoading
+func newTestGame(t *testing.T) *testGame {
+	t.Helper()
+
+	config := DefaultConfig()
+	localeMessages, err := GetLocaleMessageMapByLanguage("en")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ui, window := NewHeadlessUI(config.WindowConfig())
+
+	manager, err := NewGame(GameConfig{
+		Config:          config,
+		Seed:            1,
+		UI:              ui,
+		LocaleMessages:  localeMessages,
+		WorldPath:       "assets/world.json",
+		TilemapDir:      "assets/tilemaps/",
+		SpritesheetPath: "assets/spritesheet.png",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &testGame{
+		manager:  manager,
+		systems:  manager.SystemsManager,
+		window:   window,
+		entities: manager.Entities,
+		roomData: manager.RoomData,
+	}
+}
+
+// frame runs one frame of the game, with the buttons held down
+func (g *testGame) frame(buttons ...pixelgl.Button) {
+	for _, button := range buttons {
+		g.window.Press(button)
+	}
+	g.window.Update()
+	g.manager.Update(testDt)
+	for _, button := range buttons {
+		g.window.Release(button)
+	}
+}
+
+// start runs the game until the assets have loaded, and presses enter on the
+// start screen
+func (g *testGame) start(t *testing.T) {
+	t.Helper()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for g.manager.Current() == StateLoading || g.manager.Current() == "" {
+		if time.Now().After(deadline) {
+			t.Fatal("assets did not load")
+		}
+		g.frame()
+		time.Sleep(time.Millisecond)
+	}
+	if g.manager.Err != nil {
+		t.Fatal(g.manager.Err)
+	}
+	if got := g.manager.Current(); got != StateStart {
+		t.Fatalf("state after loading = %s, want %s", got, StateStart)
+	}
+
+	g.frame(pixelgl.KeyEnter)
+	g.frame()
+	if got := g.manager.Current(); got != StateGame {
+		t.Fatalf("state after enter = %s, want %s", got, StateGame)
+	}
+}
+
+func TestHeadlessGame(t *testing.T) {
+	g := newTestGame(t)
+	g.start(t)
+
+	if g.window.DrawCalls == 0 {
+		t.Error("nothing was drawn")
+	}
+
+	before := g.entities.Player.ComponentSpatial.Rect
+	for i := 0; i < 10; i++ {
+		g.frame(pixelgl.KeyUp)
+	}
+	after := g.entities.Player.ComponentSpatial.Rect
+	if after.Min.Y <= before.Min.Y {
+		t.Errorf("player did not move up: before %v, after %v", before, after)
+	}
+	if after.Min.X != before.Min.X {
+		t.Errorf("player moved sideways: before %v, after %v", before, after)
+	}
+}
+
+func TestHeadlessGameScrollsLargeRoom(t *testing.T) {
+	g := newTestGame(t)
+	g.start(t)
+	player := g.entities.Player.ComponentSpatial
+
+	// Line up with the door on the right of the first room, which leads
+	// through room 10 to the arena, room 12
+	for i := 0; player.Rect.Min.Y < 264 && i < 600; i++ {
+		g.frame(pixelgl.KeyUp)
+	}
+	for i := 0; g.roomData.CurrentRoomID != 12 && i < 1200; i++ {
+		g.frame(pixelgl.KeyRight)
+	}
+	if got := g.roomData.CurrentRoomID; got != 12 {
+		t.Fatalf("room = %d, want the arena, 12; player at %v", got, player.Rect)
+	}
+	for i := 0; g.manager.Current() != StateGame && i < 120; i++ {
+		g.frame()
+	}
+
+	// The player enters by the arena's left door, at the height it left by
+	if player.Rect.Min.X > 48 || player.Rect.Min.Y < 240 || player.Rect.Max.Y > 336 {
+		t.Errorf("player entered the arena at %v, want the left door", player.Rect)
+	}
+
+	// Walking right scrolls the camera across the arena
+	before := g.manager.Camera.Offset()
+	for i := 0; i < 120; i++ {
+		g.frame(pixelgl.KeyRight)
+	}
+	if after := g.manager.Camera.Offset(); after.X >= before.X {
+		t.Errorf("camera offset went from %v to %v as the player walked right, want it to scroll", before, after)
+	}
+}
+
+func TestHeadlessGameFailsOnMissingMap(t *testing.T) {
+	tests := []struct {
+		name   string
+		enter  func(g *testGame)
+		frames int
+	}{
+		// GameStateMapTransition.Enter loads the next room's map for a slide,
+		// and fails the game in the same frame
+		{"slide", func(g *testGame) {
+			RoomsMap[1].SetConnectedRoom(DirectionDown, 99)
+			g.manager.RoomTransitionManager.SetSlideStart(BoundBottom)
+		}, 1},
+		// Update loads it once a warp's timer runs out, and the failure is
+		// applied at the start of the next frame
+		{"warp", func(g *testGame) {
+			g.manager.RoomTransitionManager.SetWarp()
+			g.roomData.NextRoomID = 99
+		}, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newTestGame(t)
+			g.start(t)
+			RoomsMap[99] = NewRoom("missing")
+
+			tt.enter(g)
+			g.manager.Change(StateMapTransition)
+			for i := 0; i < tt.frames; i++ {
+				g.frame()
+			}
+
+			if got := g.manager.Current(); got != StateError {
+				t.Fatalf("state = %s, want %s", got, StateError)
+			}
+			if g.manager.Err == nil || !strings.Contains(g.manager.Err.Error(), "missing.tmx") {
+				t.Errorf("Err = %v, want the missing map", g.manager.Err)
+			}
+		})
+	}
+}
+
+func TestHeadlessGameFailsOnUnknownRoom(t *testing.T) {
+	g := newTestGame(t)
+	g.start(t)
+
+	// A warp to a room that is not in the world, as the warp collision
+	// handler starts it
+	delete(RoomsMap, 99)
+	g.manager.RoomTransitionManager.SetWarp()
+	g.roomData.NextRoomID = 99
+	g.manager.SystemsManager.SetShouldAddEntities(true)
+	g.manager.Change(StateMapTransition)
+	for i := 0; i < 4; i++ {
+		g.frame()
+	}
+
+	if got := g.manager.Current(); got != StateError {
+		t.Fatalf("state = %s, want %s", got, StateError)
+	}
+	if g.manager.Err == nil || !strings.Contains(g.manager.Err.Error(), "room 99 does not exist") {
+		t.Errorf("Err = %v, want the unknown room", g.manager.Err)
+	}
+}
+
+func TestHeadlessGameFailsOnWarpToUnknownRoom(t *testing.T) {
+	g := newTestGame(t)
+	// Room 5's map places warps to room 11
+	delete(RoomsMap, 11)
+	g.manager.Maps.SetRooms(RoomsMap)
+	g.start(t)
+
+	g.manager.RoomTransitionManager.SetWarp()
+	g.roomData.NextRoomID = 5
+	g.manager.SystemsManager.SetShouldAddEntities(true)
+	g.manager.Change(StateMapTransition)
+	for i := 0; i < 4; i++ {
+		g.frame()
+	}
+
+	if got := g.manager.Current(); got != StateError {
+		t.Fatalf("state = %s, want %s", got, StateError)
+	}
+	if g.manager.Err == nil || !strings.Contains(g.manager.Err.Error(), "warp to room 11, which does not exist") {
+		t.Errorf("Err = %v, want the warp to room 11", g.manager.Err)
+	}
+}
+
+func TestHeadlessGameCancelsRoomTimersOnUnload(t *testing.T) {
+	g := newTestGame(t)
+	g.start(t)
+
+	sys, _ := g.systems.System(SystemNameScheduler)
+	scheduler := sys.(*SystemScheduler)
+	id := scheduler.AfterInRoom(g.roomData.CurrentRoomID, time.Hour, func() {})
+
+	g.manager.Rooms.Unload()
+	if scheduler.Active(id) {
+		t.Error("the room's timer is still active after the room was unloaded")
+	}
+}
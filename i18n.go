@@ -10,11 +10,15 @@ var localeMessagesByLanguage = map[string]LocaleMessagesMap{
 		"gameTitle":             "Zelduh",
 		"pauseScreenMessage":    "Paused",
 		"gameOverScreenMessage": "Game Over",
+		"loadingScreenMessage":  "Loading",
+		"errorScreenMessage":    "Something went wrong",
 	},
 	"es": {
 		"gameTitle":             "Zelduh",
 		"pauseScreenMessage":    "Paused",
 		"gameOverScreenMessage": "Game Over",
+		"loadingScreenMessage":  "Loading",
+		"errorScreenMessage":    "Something went wrong",
 	},
 }
 
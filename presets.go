@@ -1,53 +1,77 @@
 package zelduh
 
 import (
+	"fmt"
+
+	"github.com/faiface/pixel"
 	"github.com/faiface/pixel/imdraw"
 )
 
 // GetPreset gets an entity config preset function by key
-func GetPreset(key string) entityConfigPresetFn {
-	return entityPresets[key]
+//
+// Preset functions take room coordinates in tiles, or screen coordinates in
+// tiles for HUD presets.
+func GetPreset(key string) (entityConfigPresetFn, error) {
+	preset, ok := entityPresets[key]
+	if !ok {
+		return nil, fmt.Errorf("unknown preset %q", key)
+	}
+	return preset, nil
+}
+
+// MustGetPreset is like GetPreset, but panics if there is no preset with the
+// key; it is for keys written in code, rather than read from content
+func MustGetPreset(key string) entityConfigPresetFn {
+	preset, err := GetPreset(key)
+	if err != nil {
+		panic(err)
+	}
+	return preset
 }
 
-type entityConfigPresetFn = func(xTiles, yTiles float64) EntityConfig
+type entityConfigPresetFn = func(tileSize, xTiles, yTiles float64) EntityConfig
 
 var entityPresets = map[string]entityConfigPresetFn{
-	"arrow": func(xTiles, yTiles float64) EntityConfig {
+	"arrow": func(tileSize, xTiles, yTiles float64) EntityConfig {
 		return EntityConfig{
-			Category: CategoryArrow,
+			Category:   CategoryArrow,
+			Persistent: true,
 			Movement: &MovementConfig{
 				Direction: DirectionDown,
 				Speed:     0.0,
 			},
-			W: TileSize,
-			H: TileSize,
-			X: TileSize * xTiles,
-			Y: TileSize * yTiles,
+			W: tileSize,
+			H: tileSize,
+			X: tileSize * xTiles,
+			Y: tileSize * yTiles,
 			Animation: AnimationConfig{
-				"up":    GetSpriteSet("arrowUp"),
-				"right": GetSpriteSet("arrowRight"),
-				"down":  GetSpriteSet("arrowDown"),
-				"left":  GetSpriteSet("arrowLeft"),
+				"up":    GetAnimationClip("arrowUp"),
+				"right": GetAnimationClip("arrowRight"),
+				"down":  GetAnimationClip("arrowDown"),
+				"left":  GetAnimationClip("arrowLeft"),
 			},
 			Hitbox: &HitboxConfig{
 				Radius: 5,
 			},
-			Ignore: true,
+			// Carried by the player until it is fired
+			Attachment: &AttachmentConfig{},
+			Ignore:     true,
 		}
 	},
-	"bomb": func(xTiles, yTiles float64) EntityConfig {
+	"bomb": func(tileSize, xTiles, yTiles float64) EntityConfig {
 		return EntityConfig{
-			Category: CategoryBomb,
+			Category:   CategoryBomb,
+			Persistent: true,
 			Movement: &MovementConfig{
 				Direction: DirectionDown,
 				Speed:     0.0,
 			},
-			W: TileSize,
-			H: TileSize,
-			X: TileSize * xTiles,
-			Y: TileSize * yTiles,
+			W: tileSize,
+			H: tileSize,
+			X: tileSize * xTiles,
+			Y: tileSize * yTiles,
 			Animation: AnimationConfig{
-				"default": GetSpriteSet("bomb"),
+				"default": GetAnimationClip("bomb"),
 			},
 			Hitbox: &HitboxConfig{
 				Radius: 5,
@@ -55,44 +79,49 @@ var entityPresets = map[string]entityConfigPresetFn{
 			Ignore: true,
 		}
 	},
-	"coin": func(xTiles, yTiles float64) EntityConfig {
+	"coin": func(tileSize, xTiles, yTiles float64) EntityConfig {
 		return EntityConfig{
 			Category: CategoryCoin,
-			W:        TileSize,
-			H:        TileSize,
-			X:        TileSize * xTiles,
-			Y:        TileSize * yTiles,
+			W:        tileSize,
+			H:        tileSize,
+			X:        tileSize * xTiles,
+			Y:        tileSize * yTiles,
 			Animation: AnimationConfig{
-				"default": GetSpriteSet("coin"),
+				"default": GetAnimationClip("coin"),
 			},
 		}
 	},
-	"explosion": func(xTiles, yTiles float64) EntityConfig {
+	"explosion": func(tileSize, xTiles, yTiles float64) EntityConfig {
 		return EntityConfig{
-			Category:   CategoryExplosion,
-			Expiration: 12,
+			Category:             CategoryExplosion,
+			ExpiresWithAnimation: true,
+			W:                    tileSize,
+			H:                    tileSize,
+			X:                    tileSize * xTiles,
+			Y:                    tileSize * yTiles,
 			Animation: AnimationConfig{
-				"default": GetSpriteSet("explosion"),
+				"default": GetAnimationClip("explosion"),
 			},
 		}
 	},
-	"obstacle": func(xTiles, yTiles float64) EntityConfig {
+	"obstacle": func(tileSize, xTiles, yTiles float64) EntityConfig {
 		return EntityConfig{
 			Category: CategoryObstacle,
-			W:        TileSize,
-			H:        TileSize,
-			X:        TileSize * xTiles,
-			Y:        TileSize * yTiles,
+			W:        tileSize,
+			H:        tileSize,
+			X:        tileSize * xTiles,
+			Y:        tileSize * yTiles,
 		}
 	},
-	"player": func(xTiles, yTiles float64) EntityConfig {
+	"player": func(tileSize, xTiles, yTiles float64) EntityConfig {
 		return EntityConfig{
-			Category: CategoryPlayer,
-			Health:   3,
-			W:        TileSize,
-			H:        TileSize,
-			X:        TileSize * xTiles,
-			Y:        TileSize * yTiles,
+			Category:   CategoryPlayer,
+			Persistent: true,
+			Health:     3,
+			W:          tileSize,
+			H:          tileSize,
+			X:          tileSize * xTiles,
+			Y:          tileSize * yTiles,
 			Hitbox: &HitboxConfig{
 				Box:                  imdraw.New(nil),
 				Radius:               15,
@@ -100,230 +129,191 @@ var entityPresets = map[string]entityConfigPresetFn{
 			},
 			Movement: &MovementConfig{
 				Direction: DirectionDown,
-				MaxSpeed:  7.0,
+				MaxSpeed:  420.0,
 				Speed:     0.0,
 			},
 			Coins: true,
 			Dash: &DashConfig{
 				Charge:    0,
 				MaxCharge: 50,
-				SpeedMod:  7,
+				SpeedMod:  420,
 			},
 			Animation: AnimationConfig{
-				"up":               GetSpriteSet("playerUp"),
-				"right":            GetSpriteSet("playerRight"),
-				"down":             GetSpriteSet("playerDown"),
-				"left":             GetSpriteSet("playerLeft"),
-				"swordAttackUp":    GetSpriteSet("playerSwordUp"),
-				"swordAttackRight": GetSpriteSet("playerSwordRight"),
-				"swordAttackLeft":  GetSpriteSet("playerSwordLeft"),
-				"swordAttackDown":  GetSpriteSet("playerSwordDown"),
+				"up":               GetAnimationClip("playerUp"),
+				"right":            GetAnimationClip("playerRight"),
+				"down":             GetAnimationClip("playerDown"),
+				"left":             GetAnimationClip("playerLeft"),
+				"swordAttackUp":    GetAnimationClip("playerSwordUp"),
+				"swordAttackRight": GetAnimationClip("playerSwordRight"),
+				"swordAttackLeft":  GetAnimationClip("playerSwordLeft"),
+				"swordAttackDown":  GetAnimationClip("playerSwordDown"),
 			},
 		}
 	},
-	"sword": func(xTiles, yTiles float64) EntityConfig {
+	"sword": func(tileSize, xTiles, yTiles float64) EntityConfig {
 		return EntityConfig{
-			Category: CategorySword,
+			Category:   CategorySword,
+			Persistent: true,
 			Movement: &MovementConfig{
 				Direction: DirectionDown,
 				Speed:     0.0,
 			},
-			W: TileSize,
-			H: TileSize,
-			X: TileSize * xTiles,
-			Y: TileSize * yTiles,
-			Animation: AnimationConfig{
-				"up":    GetSpriteSet("swordUp"),
-				"right": GetSpriteSet("swordRight"),
-				"down":  GetSpriteSet("swordDown"),
-				"left":  GetSpriteSet("swordLeft"),
-			},
-			Hitbox: &HitboxConfig{
-				Radius: 20,
-			},
-			Ignore: true,
-		}
-	},
-	"eyeburrower": func(xTiles, yTiles float64) EntityConfig {
-		return EntityConfig{
-			Category: CategoryEnemy,
-			W:        TileSize, H: TileSize, X: TileSize * xTiles, Y: TileSize * yTiles,
+			W: tileSize,
+			H: tileSize,
+			X: tileSize * xTiles,
+			Y: tileSize * yTiles,
 			Animation: AnimationConfig{
-				"default": GetSpriteSet("eyeburrower"),
+				"up":    GetAnimationClip("swordUp"),
+				"right": GetAnimationClip("swordRight"),
+				"down":  GetAnimationClip("swordDown"),
+				"left":  GetAnimationClip("swordLeft"),
 			},
-			Health: 2,
 			Hitbox: &HitboxConfig{
-				Box:    imdraw.New(nil),
 				Radius: 20,
 			},
-			Movement: &MovementConfig{
-				Direction:    DirectionDown,
-				Speed:        1.0,
-				MaxSpeed:     1.0,
-				HitSpeed:     10.0,
-				HitBackMoves: 10,
-				MaxMoves:     100,
-				PatternName:  "random",
+			// Held one tile in front of the player
+			Attachment: &AttachmentConfig{
+				Offsets: map[Direction]pixel.Vec{
+					DirectionUp:    pixel.V(0, tileSize),
+					DirectionRight: pixel.V(tileSize, 0),
+					DirectionDown:  pixel.V(0, -tileSize),
+					DirectionLeft:  pixel.V(-tileSize, 0),
+				},
 			},
+			Ignore: true,
 		}
 	},
-	"heart": func(xTiles, yTiles float64) EntityConfig {
+	"eyeburrower": extendPreset(enemyPreset, WithAnimation("default", "eyeburrower")),
+	"heart": func(tileSize, xTiles, yTiles float64) EntityConfig {
 		return EntityConfig{
-			Category: CategoryHeart,
-			W:        TileSize,
-			H:        TileSize,
-			X:        TileSize * xTiles,
-			Y:        TileSize * yTiles,
+			Category:   CategoryHeart,
+			Persistent: true,
+			HUD:        true,
+			W:          tileSize,
+			H:          tileSize,
+			X:          tileSize * xTiles,
+			Y:          tileSize * yTiles,
 			Hitbox: &HitboxConfig{
 				Box: imdraw.New(nil),
 			},
 			Animation: AnimationConfig{
-				"default": GetSpriteSet("heart"),
+				"default": GetAnimationClip("heart"),
 			},
 		}
 
 	},
-	"skeleton": func(xTiles, yTiles float64) EntityConfig {
+	"skeleton": extendPreset(enemyPreset, WithAnimation("default", "skeleton")),
+	"skull":    extendPreset(enemyPreset, WithAnimation("default", "skull")),
+	"spinner": func(tileSize, xTiles, yTiles float64) EntityConfig {
 		return EntityConfig{
 			Category: CategoryEnemy,
-			W:        TileSize, H: TileSize, X: TileSize * xTiles, Y: TileSize * yTiles,
+			W:        tileSize, H: tileSize, X: tileSize * xTiles, Y: tileSize * yTiles,
 			Animation: AnimationConfig{
-				"default": GetSpriteSet("skeleton"),
+				"default": GetAnimationClip("spinner"),
 			},
-			Health: 2,
+			Invincible: true,
 			Hitbox: &HitboxConfig{
 				Box:    imdraw.New(nil),
 				Radius: 20,
 			},
 			Movement: &MovementConfig{
-				Direction:    DirectionDown,
-				Speed:        1.0,
-				MaxSpeed:     1.0,
-				HitSpeed:     10.0,
+				Direction:    DirectionRight,
+				Speed:        60.0,
+				MaxSpeed:     60.0,
+				HitSpeed:     600.0,
 				HitBackMoves: 10,
 				MaxMoves:     100,
-				PatternName:  "random",
+				PatternName:  "left-right",
 			},
 		}
 	},
-	"skull": func(xTiles, yTiles float64) EntityConfig {
+	"uiCoin": func(tileSize, xTiles, yTiles float64) EntityConfig {
 		return EntityConfig{
-			Category: CategoryEnemy,
-			W:        TileSize, H: TileSize, X: TileSize * xTiles, Y: TileSize * yTiles,
-			Animation: AnimationConfig{
-				"default": GetSpriteSet("skull"),
-			},
-			Health: 2,
+			Category:   CategoryHeart,
+			Persistent: true,
+			HUD:        true,
+			W:          tileSize,
+			H:          tileSize,
+			X:          tileSize * xTiles,
+			Y:          tileSize * yTiles,
 			Hitbox: &HitboxConfig{
-				Box:    imdraw.New(nil),
-				Radius: 20,
-			},
-			Movement: &MovementConfig{
-				Direction:    DirectionDown,
-				Speed:        1.0,
-				MaxSpeed:     1.0,
-				HitSpeed:     10.0,
-				HitBackMoves: 10,
-				MaxMoves:     100,
-				PatternName:  "random",
+				Box: imdraw.New(nil),
 			},
-		}
-	},
-	"spinner": func(xTiles, yTiles float64) EntityConfig {
-		return EntityConfig{
-			Category: CategoryEnemy,
-			W:        TileSize, H: TileSize, X: TileSize * xTiles, Y: TileSize * yTiles,
 			Animation: AnimationConfig{
-				"default": GetSpriteSet("spinner"),
-			},
-			Invincible: true,
-			Hitbox: &HitboxConfig{
-				Box:    imdraw.New(nil),
-				Radius: 20,
-			},
-			Movement: &MovementConfig{
-				Direction:    DirectionRight,
-				Speed:        1.0,
-				MaxSpeed:     1.0,
-				HitSpeed:     10.0,
-				HitBackMoves: 10,
-				MaxMoves:     100,
-				PatternName:  "left-right",
+				"default": GetAnimationClip("uiCoin"),
 			},
 		}
 	},
-	"uiCoin": func(xTiles, yTiles float64) EntityConfig {
+	// warp is an invisible warp; rooms set where it leads with WithWarpTo
+	"warp": func(tileSize, xTiles, yTiles float64) EntityConfig {
 		return EntityConfig{
-			Category: CategoryHeart,
-			W:        TileSize,
-			H:        TileSize,
-			X:        TileSize * xTiles,
-			Y:        TileSize * yTiles,
+			Category: CategoryWarp,
+			X:        tileSize * xTiles,
+			Y:        tileSize * yTiles,
+			W:        tileSize,
+			H:        tileSize,
 			Hitbox: &HitboxConfig{
-				Box: imdraw.New(nil),
-			},
-			Animation: AnimationConfig{
-				"default": GetSpriteSet("uiCoin"),
+				Radius: 20,
 			},
 		}
 	},
-	"warpStone": func(xTiles, yTiles float64) EntityConfig {
+	"warpStone": func(tileSize, xTiles, yTiles float64) EntityConfig {
 		return EntityConfig{
 			Category: CategoryWarp,
-			X:        TileSize * xTiles,
-			Y:        TileSize * yTiles,
-			W:        TileSize,
-			H:        TileSize,
+			X:        tileSize * xTiles,
+			Y:        tileSize * yTiles,
+			W:        tileSize,
+			H:        tileSize,
 			Hitbox: &HitboxConfig{
 				Box:    imdraw.New(nil),
 				Radius: 20,
 			},
 			Animation: AnimationConfig{
-				"default": GetSpriteSet("warpStone"),
+				"default": GetAnimationClip("warpStone"),
 			},
 		}
 	},
-	"puzzleBox": func(xTiles, yTiles float64) EntityConfig {
+	"puzzleBox": func(tileSize, xTiles, yTiles float64) EntityConfig {
 		return EntityConfig{
 			Category: CategoryMovableObstacle,
-			X:        TileSize * xTiles,
-			Y:        TileSize * yTiles,
-			W:        TileSize,
-			H:        TileSize,
+			X:        tileSize * xTiles,
+			Y:        tileSize * yTiles,
+			W:        tileSize,
+			H:        tileSize,
 			Animation: AnimationConfig{
-				"default": GetSpriteSet("puzzleBox"),
+				"default": GetAnimationClip("puzzleBox"),
 			},
 			Movement: &MovementConfig{
-				Speed:    1.0,
-				MaxMoves: int(TileSize) / 2,
-				MaxSpeed: 2.0,
+				Speed:    60.0,
+				MaxMoves: int(tileSize) / 2,
+				MaxSpeed: 120.0,
 			},
 		}
 	},
-	"floorSwitch": func(xTiles, yTiles float64) EntityConfig {
+	"floorSwitch": func(tileSize, xTiles, yTiles float64) EntityConfig {
 		return EntityConfig{
 			Category: CategoryCollisionSwitch,
-			X:        TileSize * xTiles,
-			Y:        TileSize * yTiles,
-			W:        TileSize,
-			H:        TileSize,
+			X:        tileSize * xTiles,
+			Y:        tileSize * yTiles,
+			W:        tileSize,
+			H:        tileSize,
 			Animation: AnimationConfig{
-				"default": GetSpriteSet("floorSwitch"),
+				"default": GetAnimationClip("floorSwitch"),
 			},
 			Toggleable: true,
 		}
 	},
 	// this is an impassable obstacle that can be toggled "remotely"
 	// it has two visual states that coincide with each toggle state
-	"toggleObstacle": func(xTiles, yTiles float64) EntityConfig {
+	"toggleObstacle": func(tileSize, xTiles, yTiles float64) EntityConfig {
 		// TODO get this working again
 		return EntityConfig{
-			X: TileSize * xTiles,
-			Y: TileSize * yTiles,
-			W: TileSize,
-			H: TileSize,
+			X: tileSize * xTiles,
+			Y: tileSize * yTiles,
+			W: tileSize,
+			H: tileSize,
 			Animation: AnimationConfig{
-				"default": GetSpriteSet("toggleObstacle"),
+				"default": GetAnimationClip("toggleObstacle"),
 			},
 			// Impassable: true,
 			Toggleable: true,
@@ -332,9 +322,41 @@ var entityPresets = map[string]entityConfigPresetFn{
 }
 
 // WarpStone returns an entity config for a warp stone
-func WarpStone(X, Y, WarpToRoomID, HitBoxRadius float64) EntityConfig {
-	e := entityPresets["warpStone"](X, Y)
-	e.WarpToRoomID = 6
-	e.Hitbox.Radius = 5
-	return e
+func WarpStone(tileSize, X, Y float64, warpToRoomID RoomID, hitBoxRadius float64) EntityConfig {
+	return MustGetPreset("warpStone")(tileSize, X, Y).With(
+		WithWarpTo(warpToRoomID),
+		WithHitboxRadius(hitBoxRadius),
+	)
+}
+
+// enemyPreset is the base of the enemies that wander randomly
+//
+// It has no animation; presets extending it set the "default" clip.
+func enemyPreset(tileSize, xTiles, yTiles float64) EntityConfig {
+	return EntityConfig{
+		Category: CategoryEnemy,
+		W:        tileSize, H: tileSize, X: tileSize * xTiles, Y: tileSize * yTiles,
+		Health: 2,
+		Hitbox: &HitboxConfig{
+			Box:    imdraw.New(nil),
+			Radius: 20,
+		},
+		Movement: &MovementConfig{
+			Direction:    DirectionDown,
+			Speed:        60.0,
+			MaxSpeed:     60.0,
+			HitSpeed:     600.0,
+			HitBackMoves: 10,
+			MaxMoves:     100,
+			PatternName:  "random",
+		},
+	}
+}
+
+// extendPreset returns a preset that builds the base preset's config, then
+// applies the overrides
+func extendPreset(base entityConfigPresetFn, overrides ...PresetOverride) entityConfigPresetFn {
+	return func(tileSize, xTiles, yTiles float64) EntityConfig {
+		return base(tileSize, xTiles, yTiles).With(overrides...)
+	}
 }
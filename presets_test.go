@@ -0,0 +1,117 @@
+package zelduh
+
+import "testing"
+
+func TestDerivedEnemyPresetsKeepBase(t *testing.T) {
+	base := enemyPreset(48, 2, 3)
+	for _, key := range []string{"skull", "skeleton", "eyeburrower"} {
+		t.Run(key, func(t *testing.T) {
+			c := MustGetPreset(key)(48, 2, 3)
+			if c.Category != base.Category || c.Health != base.Health {
+				t.Errorf("category %v, health %d; want %v, %d", c.Category, c.Health, base.Category, base.Health)
+			}
+			if c.X != base.X || c.Y != base.Y || c.W != base.W || c.H != base.H {
+				t.Errorf("rect %v,%v %vx%v; want %v,%v %vx%v", c.X, c.Y, c.W, c.H, base.X, base.Y, base.W, base.H)
+			}
+			if c.Movement == nil || *c.Movement != *base.Movement {
+				t.Errorf("Movement = %+v, want %+v", c.Movement, base.Movement)
+			}
+			if c.Hitbox == nil || c.Hitbox.Radius != base.Hitbox.Radius {
+				t.Errorf("Hitbox = %+v, want radius %v", c.Hitbox, base.Hitbox.Radius)
+			}
+			got, want := c.Animation["default"].SpriteIDs(), GetAnimationClip(key).SpriteIDs()
+			if !equalInts(got, want) {
+				t.Errorf("default animation = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestPresetOverridesDoNotChangeBase(t *testing.T) {
+	skull := MustGetPreset("skull")
+	a := skull(48, 1, 1)
+	b := a.With(
+		WithSpeed(10),
+		WithPattern("left-right"),
+		WithHealth(5),
+		WithHitboxRadius(3),
+		WithTags("boss"),
+		WithName("boss"),
+		WithAnimation("hit", "skeleton"),
+	)
+
+	if b.Movement == a.Movement || b.Hitbox == a.Hitbox {
+		t.Fatal("the override shares a sub config with the config it was applied to")
+	}
+	if b.Movement.Speed != 10 || b.Movement.PatternName != "left-right" || b.Health != 5 || b.Hitbox.Radius != 3 {
+		t.Errorf("overrides were not applied: %+v, %+v, health %d", *b.Movement, *b.Hitbox, b.Health)
+	}
+	if b.Movement.HitSpeed != a.Movement.HitSpeed {
+		t.Errorf("HitSpeed = %v, want the base %v", b.Movement.HitSpeed, a.Movement.HitSpeed)
+	}
+	if _, ok := b.Animation["default"]; !ok {
+		t.Error("WithAnimation dropped the default animation")
+	}
+
+	// Neither the config the overrides were applied to, nor the next config
+	// the preset builds, changed
+	for name, c := range map[string]EntityConfig{"original": a, "rebuilt": skull(48, 1, 1)} {
+		if c.Movement.Speed != 60 || c.Movement.PatternName != "random" || c.Health != 2 || c.Hitbox.Radius != 20 {
+			t.Errorf("%s config changed: %+v, %+v, health %d", name, *c.Movement, *c.Hitbox, c.Health)
+		}
+		if len(c.Tags) != 0 || c.Name != "" {
+			t.Errorf("%s config has tags %v and name %q", name, c.Tags, c.Name)
+		}
+		if _, ok := c.Animation["hit"]; ok {
+			t.Errorf("%s config has the hit animation", name)
+		}
+	}
+}
+
+func TestPresetInstancesDoNotShareState(t *testing.T) {
+	skull := MustGetPreset("skull")
+	a, b := skull(48, 1, 1), skull(48, 1, 1)
+	if a.Movement == b.Movement || a.Hitbox == b.Hitbox {
+		t.Fatal("two configs from the same preset share sub configs")
+	}
+
+	config := skull(48, 1, 1)
+	first := BuildEntityFromConfig(config, newEntityID(1, 1), 4)
+	second := BuildEntityFromConfig(config, newEntityID(2, 1), 4)
+	first.ComponentMovement.Speed = 0
+	first.ComponentHealth.Total = 0
+	if second.ComponentMovement.Speed != 60 || second.ComponentHealth.Total != 2 {
+		t.Errorf("changing one entity changed another built from the same config: speed %v, health %d",
+			second.ComponentMovement.Speed, second.ComponentHealth.Total)
+	}
+	if config.Movement.Speed != 60 {
+		t.Errorf("changing an entity changed its config: speed %v", config.Movement.Speed)
+	}
+}
+
+func TestWarpStone(t *testing.T) {
+	for _, roomID := range []RoomID{6, 9} {
+		c := WarpStone(48, 2, 5, roomID, 5)
+		if c.WarpToRoomID != roomID {
+			t.Errorf("WarpToRoomID = %d, want %d", c.WarpToRoomID, roomID)
+		}
+		if c.Category != CategoryWarp || c.Hitbox.Radius != 5 {
+			t.Errorf("category %v, hitbox radius %v; want a warp with radius 5", c.Category, c.Hitbox.Radius)
+		}
+		if c.X != 96 || c.Y != 240 {
+			t.Errorf("position %v,%v, want 96,240", c.X, c.Y)
+		}
+	}
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
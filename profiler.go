@@ -0,0 +1,213 @@
+package zelduh
+
+import (
+	"encoding/json"
+	"io"
+	"runtime/metrics"
+	"sort"
+	"time"
+)
+
+// profilerFrameSamples is how many recent frames frame time percentiles are taken over
+const profilerFrameSamples = 300
+
+// SystemProfile holds the timings of one system
+//
+// Update times include the event handlers and entity commands the update
+// triggered. Entities is the number of entities the system processed in its
+// last update, for systems that implement SystemQuerier, and -1 otherwise.
+type SystemProfile struct {
+	Name        string
+	Updates     int
+	UpdateTotal time.Duration
+	UpdateLast  time.Duration
+	UpdateMax   time.Duration
+	Draws       int
+	DrawTotal   time.Duration
+	DrawLast    time.Duration
+	DrawMax     time.Duration
+	Entities    int
+}
+
+// Profiler times the systems of a SystemsManager, and the frames of the game
+//
+// It does nothing until Enabled is set. Frames are measured between
+// BeginFrame and EndFrame, which the game loop calls once per frame.
+type Profiler struct {
+	Enabled bool
+
+	systems      map[string]*SystemProfile
+	systemOrder  []string
+	frames       int
+	frameTimes   [profilerFrameSamples]time.Duration
+	frameAllocs  [profilerFrameSamples]uint64
+	frameStart   time.Time
+	mallocsStart uint64
+}
+
+func (p *Profiler) system(name string) *SystemProfile {
+	if p.systems == nil {
+		p.systems = map[string]*SystemProfile{}
+	}
+	profile, ok := p.systems[name]
+	if !ok {
+		profile = &SystemProfile{Name: name, Entities: -1}
+		p.systems[name] = profile
+		p.systemOrder = append(p.systemOrder, name)
+	}
+	return profile
+}
+
+func (p *Profiler) recordUpdate(name string, d time.Duration, entities int) {
+	profile := p.system(name)
+	profile.Updates++
+	profile.UpdateTotal += d
+	profile.UpdateLast = d
+	if d > profile.UpdateMax {
+		profile.UpdateMax = d
+	}
+	profile.Entities = entities
+}
+
+func (p *Profiler) recordDraw(name string, d time.Duration) {
+	profile := p.system(name)
+	profile.Draws++
+	profile.DrawTotal += d
+	profile.DrawLast = d
+	if d > profile.DrawMax {
+		profile.DrawMax = d
+	}
+}
+
+// BeginFrame marks the start of a frame
+func (p *Profiler) BeginFrame() {
+	if !p.Enabled {
+		return
+	}
+	p.frameStart = time.Now()
+	p.mallocsStart = mallocs()
+}
+
+// EndFrame marks the end of the frame started by BeginFrame, and records its
+// duration and the number of heap allocations made during it
+func (p *Profiler) EndFrame() {
+	if !p.Enabled || p.frameStart.IsZero() {
+		return
+	}
+	i := p.frames % profilerFrameSamples
+	p.frameTimes[i] = time.Since(p.frameStart)
+	p.frameAllocs[i] = mallocs() - p.mallocsStart
+	p.frames++
+	p.frameStart = time.Time{}
+}
+
+// mallocsSample reads the cumulative count of heap allocations; unlike
+// runtime.ReadMemStats, reading it does not stop the world
+var mallocsSample = []metrics.Sample{{Name: "/gc/heap/allocs:objects"}}
+
+func mallocs() uint64 {
+	metrics.Read(mallocsSample)
+	if mallocsSample[0].Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+	return mallocsSample[0].Value.Uint64()
+}
+
+// ProfileReport is a snapshot of a Profiler, in milliseconds
+type ProfileReport struct {
+	Frames         int                   `json:"frames"`
+	FrameMsP50     float64               `json:"frameMsP50"`
+	FrameMsP95     float64               `json:"frameMsP95"`
+	FrameMsP99     float64               `json:"frameMsP99"`
+	FrameMsMax     float64               `json:"frameMsMax"`
+	AllocsPerFrame float64               `json:"allocsPerFrame"`
+	Systems        []SystemProfileReport `json:"systems"`
+}
+
+// SystemProfileReport is a snapshot of a SystemProfile, in milliseconds
+type SystemProfileReport struct {
+	Name         string  `json:"name"`
+	Updates      int     `json:"updates"`
+	UpdateMsAvg  float64 `json:"updateMsAvg"`
+	UpdateMsLast float64 `json:"updateMsLast"`
+	UpdateMsMax  float64 `json:"updateMsMax"`
+	Draws        int     `json:"draws"`
+	DrawMsAvg    float64 `json:"drawMsAvg"`
+	DrawMsLast   float64 `json:"drawMsLast"`
+	DrawMsMax    float64 `json:"drawMsMax"`
+	Entities     int     `json:"entities"`
+}
+
+// Report returns the timings so far
+//
+// Frame time percentiles and allocations are taken over the most recent frames.
+func (p *Profiler) Report() ProfileReport {
+	report := ProfileReport{
+		Frames:  p.frames,
+		Systems: []SystemProfileReport{},
+	}
+
+	n := p.frames
+	if n > profilerFrameSamples {
+		n = profilerFrameSamples
+	}
+	if n > 0 {
+		times := make([]time.Duration, n)
+		copy(times, p.frameTimes[:n])
+		sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+		report.FrameMsP50 = ms(percentile(times, 50))
+		report.FrameMsP95 = ms(percentile(times, 95))
+		report.FrameMsP99 = ms(percentile(times, 99))
+		report.FrameMsMax = ms(times[n-1])
+
+		var allocs uint64
+		for _, a := range p.frameAllocs[:n] {
+			allocs += a
+		}
+		report.AllocsPerFrame = float64(allocs) / float64(n)
+	}
+
+	for _, name := range p.systemOrder {
+		profile := p.systems[name]
+		report.Systems = append(report.Systems, SystemProfileReport{
+			Name:         name,
+			Updates:      profile.Updates,
+			UpdateMsAvg:  average(profile.UpdateTotal, profile.Updates),
+			UpdateMsLast: ms(profile.UpdateLast),
+			UpdateMsMax:  ms(profile.UpdateMax),
+			Draws:        profile.Draws,
+			DrawMsAvg:    average(profile.DrawTotal, profile.Draws),
+			DrawMsLast:   ms(profile.DrawLast),
+			DrawMsMax:    ms(profile.DrawMax),
+			Entities:     profile.Entities,
+		})
+	}
+	return report
+}
+
+// WriteJSON writes Report as JSON
+func (p *Profiler) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(p.Report())
+}
+
+// percentile returns the pth percentile of sorted, using the nearest rank
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+func average(total time.Duration, count int) float64 {
+	if count == 0 {
+		return 0
+	}
+	return ms(total) / float64(count)
+}
@@ -0,0 +1,100 @@
+package zelduh
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPercentile(t *testing.T) {
+	sorted := []time.Duration{}
+	for i := 1; i <= 10; i++ {
+		sorted = append(sorted, time.Duration(i)*time.Millisecond)
+	}
+
+	tests := []struct {
+		name   string
+		sorted []time.Duration
+		p      int
+		want   time.Duration
+	}{
+		{"one sample", sorted[:1], 99, time.Millisecond},
+		{"p0 is the smallest", sorted, 0, time.Millisecond},
+		{"p50", sorted, 50, 5 * time.Millisecond},
+		{"p50 rounds the rank up", sorted[:5], 50, 3 * time.Millisecond},
+		{"p95", sorted, 95, 10 * time.Millisecond},
+		{"p100 is the largest", sorted, 100, 10 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := percentile(tt.sorted, tt.p); got != tt.want {
+				t.Errorf("percentile(%d) = %v, want %v", tt.p, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProfilerReport(t *testing.T) {
+	p := &Profiler{}
+	if report := p.Report(); report.Frames != 0 || report.FrameMsMax != 0 || len(report.Systems) != 0 {
+		t.Errorf("empty report = %+v", report)
+	}
+
+	// Record more frames than are sampled; the first ones fall out of the window
+	for i := 0; i < profilerFrameSamples+10; i++ {
+		d := time.Millisecond
+		if i < 10 {
+			d = time.Second
+		}
+		p.frameTimes[p.frames%profilerFrameSamples] = d
+		p.frameAllocs[p.frames%profilerFrameSamples] = 2
+		p.frames++
+	}
+	p.recordUpdate("input", 2*time.Millisecond, -1)
+	p.recordUpdate("spatial", time.Millisecond, 3)
+	p.recordUpdate("spatial", 3*time.Millisecond, 5)
+	p.recordDraw("render", 4*time.Millisecond)
+
+	report := p.Report()
+	if report.Frames != profilerFrameSamples+10 {
+		t.Errorf("Frames = %d, want %d", report.Frames, profilerFrameSamples+10)
+	}
+	if report.FrameMsMax != 1 || report.FrameMsP99 != 1 {
+		t.Errorf("FrameMsMax = %v, FrameMsP99 = %v, want 1, as older frames are dropped", report.FrameMsMax, report.FrameMsP99)
+	}
+	if report.AllocsPerFrame != 2 {
+		t.Errorf("AllocsPerFrame = %v, want 2", report.AllocsPerFrame)
+	}
+
+	want := []SystemProfileReport{
+		{Name: "input", Updates: 1, UpdateMsAvg: 2, UpdateMsLast: 2, UpdateMsMax: 2, Entities: -1},
+		{Name: "spatial", Updates: 2, UpdateMsAvg: 2, UpdateMsLast: 3, UpdateMsMax: 3, Entities: 5},
+		{Name: "render", Draws: 1, DrawMsAvg: 4, DrawMsLast: 4, DrawMsMax: 4, Entities: -1},
+	}
+	if len(report.Systems) != len(want) {
+		t.Fatalf("Systems = %+v, want %+v", report.Systems, want)
+	}
+	for i := range want {
+		if report.Systems[i] != want[i] {
+			t.Errorf("Systems[%d] = %+v, want %+v", i, report.Systems[i], want[i])
+		}
+	}
+}
+
+func TestProfilerCountsAllocations(t *testing.T) {
+	p := &Profiler{Enabled: true}
+	var keep [][]byte
+	p.BeginFrame()
+	for i := 0; i < 1000; i++ {
+		keep = append(keep, make([]byte, 1024))
+	}
+	p.EndFrame()
+
+	if len(keep) != 1000 {
+		t.Fatal("allocations were optimized away")
+	}
+	// The runtime counts some allocations late, as its per-thread caches are
+	// flushed, so the count is only close
+	if report := p.Report(); report.Frames != 1 || report.AllocsPerFrame < 900 {
+		t.Errorf("Frames = %d, AllocsPerFrame = %v, want 1 frame with about 1000 allocations", report.Frames, report.AllocsPerFrame)
+	}
+}
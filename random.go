@@ -0,0 +1,37 @@
+package zelduh
+
+import "math/rand"
+
+// Random is the seeded source of randomness shared by every system
+//
+// All game randomness goes through it, so the same seed gives the same random
+// numbers in the same order. The game runs as many ticks each frame as the
+// frame time allows, so a replay only matches tick for tick if the same input
+// arrives on the same ticks, as it does when Update is called with a fixed dt.
+type Random struct {
+	seed int64
+	rand *rand.Rand
+}
+
+// NewRandom returns a new Random seeded with seed
+func NewRandom(seed int64) Random {
+	return Random{
+		seed: seed,
+		rand: rand.New(rand.NewSource(seed)),
+	}
+}
+
+// Seed returns the seed the Random was created with
+func (r *Random) Seed() int64 {
+	return r.seed
+}
+
+// Intn returns a random int in [0,n)
+func (r *Random) Intn(n int) int {
+	return r.rand.Intn(n)
+}
+
+// Float64 returns a random float64 in [0.0,1.0)
+func (r *Random) Float64() float64 {
+	return r.rand.Float64()
+}
@@ -0,0 +1,30 @@
+package zelduh
+
+import "testing"
+
+func TestRandomSameSeedSameSequence(t *testing.T) {
+	for _, seed := range []int64{0, 1, -42, 1234567890123} {
+		a, b := NewRandom(seed), NewRandom(seed)
+		if a.Seed() != seed {
+			t.Errorf("Seed() = %d, want %d", a.Seed(), seed)
+		}
+		for i := 0; i < 100; i++ {
+			if x, y := a.Intn(1000), b.Intn(1000); x != y {
+				t.Fatalf("seed %d: Intn #%d = %d and %d", seed, i, x, y)
+			}
+			if x, y := a.Float64(), b.Float64(); x != y {
+				t.Fatalf("seed %d: Float64 #%d = %v and %v", seed, i, x, y)
+			}
+		}
+	}
+}
+
+func TestRandomDifferentSeeds(t *testing.T) {
+	a, b := NewRandom(1), NewRandom(2)
+	for i := 0; i < 100; i++ {
+		if a.Intn(1<<30) != b.Intn(1<<30) {
+			return
+		}
+	}
+	t.Error("seeds 1 and 2 gave the same sequence")
+}
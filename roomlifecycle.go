@@ -0,0 +1,172 @@
+package zelduh
+
+import "fmt"
+
+// RoomHook is called with the ID of a room as it is loaded or unloaded
+type RoomHook func(roomID RoomID)
+
+// RoomLifecycle spawns the entities of the current room, and cleans them up
+// when the room is left
+//
+// Every entity that is not Persistent is owned by the loaded room, including
+// those spawned while it is loaded, like coin drops. Unload destroys them all
+// and clears them from EntitiesMap and RoomWarps.
+type RoomLifecycle struct {
+	SystemsManager *SystemsManager
+	EntitiesMap    EntityByEntityID
+	RoomWarps      map[EntityID]EntityConfig
+	Maps           *MapStore
+	Config         Config
+
+	loaded   RoomID
+	onLoad   []RoomHook
+	onUnload []RoomHook
+}
+
+// NewRoomLifecycle returns a new RoomLifecycle with no room loaded
+func NewRoomLifecycle(
+	systemsManager *SystemsManager,
+	entitiesMap EntityByEntityID,
+	roomWarps map[EntityID]EntityConfig,
+	maps *MapStore,
+	config Config,
+) RoomLifecycle {
+	return RoomLifecycle{
+		SystemsManager: systemsManager,
+		EntitiesMap:    entitiesMap,
+		RoomWarps:      roomWarps,
+		Maps:           maps,
+		Config:         config,
+	}
+}
+
+// OnLoad registers a hook that is called after a room's entities are spawned
+func (r *RoomLifecycle) OnLoad(hook RoomHook) {
+	r.onLoad = append(r.onLoad, hook)
+}
+
+// OnUnload registers a hook that is called before a room's entities are destroyed
+func (r *RoomLifecycle) OnUnload(hook RoomHook) {
+	r.onUnload = append(r.onUnload, hook)
+}
+
+// Loaded returns the ID of the loaded room, or 0 if no room is loaded
+func (r *RoomLifecycle) Loaded() RoomID {
+	return r.loaded
+}
+
+// Load unloads the current room, if any, and spawns the entities of the given room
+//
+// The collision system, if one is registered as SystemNameCollision, is given
+// the bounds of the room's map.
+//
+// If the room does not exist, its map cannot be loaded, or an entity's name is
+// taken by another entity, Load spawns nothing and returns the error.
+func (r *RoomLifecycle) Load(roomID RoomID) error {
+	r.Unload()
+
+	systemsManager := r.SystemsManager
+	roomsMap := RoomsMap
+
+	room, err := roomsMap.room(roomID)
+	if err != nil {
+		return err
+	}
+	mapData, err := r.Maps.Get(room.MapName())
+	if err != nil {
+		return fmt.Errorf("room %d: %w", roomID, err)
+	}
+
+	// Draw obstacles on appropriate map tiles
+	obstacles, err := DrawObstaclesPerMapTiles(systemsManager, roomsMap, r.Maps, roomID, r.Config)
+	if err != nil {
+		return fmt.Errorf("room %d: %w", roomID, err)
+	}
+	systemsManager.AddEntities(obstacles...)
+
+	// Iterate through all entity configurations, those placed in the map then
+	// those of the room, and build entities and add to systems
+	configs := []EntityConfig{}
+	configs = append(configs, mapData.EntityConfigs...)
+	configs = append(configs, room.EntityConfigs...)
+	for _, c := range configs {
+		entity := BuildEntityFromConfig(c, systemsManager.NewEntityID(), r.Config.FrameRate)
+		if !systemsManager.AddEntity(entity) {
+			systemsManager.DestroyEntity(entity.ID())
+			r.clear()
+			return fmt.Errorf("room %d: entity name %q is taken", roomID, entity.Name)
+		}
+		r.EntitiesMap[entity.ID()] = entity
+
+		switch c.Category {
+		case CategoryWarp:
+			r.RoomWarps[entity.ID()] = c
+		}
+	}
+
+	// Rooms may differ in size, so the player collides with the loaded room's edges
+	sys, _ := systemsManager.System(SystemNameCollision)
+	if collision, ok := sys.(*SystemCollision); ok {
+		collision.MapBounds = mapData.Bounds
+	}
+
+	r.preloadNeighbours(room, configs)
+
+	r.loaded = roomID
+	for _, hook := range r.onLoad {
+		hook(roomID)
+	}
+
+	systemsManager.Events.Publish(RoomEnteredEvent{
+		RoomID: roomID,
+	})
+	return nil
+}
+
+// Unload destroys every entity owned by the loaded room
+func (r *RoomLifecycle) Unload() {
+	if r.loaded == 0 {
+		return
+	}
+	for _, hook := range r.onUnload {
+		hook(r.loaded)
+	}
+	r.clear()
+	r.loaded = 0
+}
+
+// clear destroys every entity that is not Persistent, and forgets the room's
+// entities and warps
+func (r *RoomLifecycle) clear() {
+	for _, entity := range r.SystemsManager.Query(0, 0) {
+		if !entity.Persistent {
+			r.SystemsManager.DestroyEntity(entity.ID())
+		}
+	}
+	for id := range r.EntitiesMap {
+		delete(r.EntitiesMap, id)
+	}
+	for id := range r.RoomWarps {
+		delete(r.RoomWarps, id)
+	}
+}
+
+// preloadNeighbours starts loading, in the background, the maps of the rooms
+// that can be reached from the room, through its sides or the warps among its
+// entity configs
+func (r *RoomLifecycle) preloadNeighbours(room *Room, configs []EntityConfig) {
+	connected := room.ConnectedRooms()
+	ids := []RoomID{connected.Top, connected.Right, connected.Bottom, connected.Left}
+	for _, c := range configs {
+		if c.Category == CategoryWarp {
+			ids = append(ids, c.WarpToRoomID)
+		}
+	}
+	names := []string{}
+	for _, id := range ids {
+		if neighbour, ok := RoomsMap[id]; ok {
+			names = append(names, neighbour.MapName())
+		}
+	}
+	r.Maps.Preload(nil, names...)
+}
@@ -0,0 +1,178 @@
+package zelduh
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/faiface/pixel"
+)
+
+func TestRoomLifecycleLoadRejectsTakenName(t *testing.T) {
+	w := NewSystemsManager(1)
+	player := BuildEntityFromConfig(MustGetPreset("player")(48, 5, 4).With(WithName("hero")), w.NewEntityID(), 4)
+	w.AddEntity(player)
+
+	RoomsMap = Rooms{
+		1: NewRoom("overworldOpen",
+			MustGetPreset("skull")(48, 3, 3),
+			MustGetPreset("skull")(48, 4, 3).With(WithName("hero")),
+		),
+	}
+	rooms := NewRoomLifecycle(&w, NewEntityByEntityIDMap(), map[EntityID]EntityConfig{}, NewMapStore("assets/tilemaps/", 48), DefaultConfig())
+
+	err := rooms.Load(1)
+	if err == nil || !strings.Contains(err.Error(), `entity name "hero" is taken`) {
+		t.Fatalf("Load err = %v, want the name to be taken", err)
+	}
+	if got := rooms.Loaded(); got != 0 {
+		t.Errorf("Loaded = %d, want 0", got)
+	}
+	if got, want := entityIDs(w.Query(0, 0)), []EntityID{player.ID()}; !equalIDs(got, want) {
+		t.Errorf("entities after the failed load = %v, want only the player %v", got, want)
+	}
+	if len(rooms.EntitiesMap) != 0 {
+		t.Errorf("EntitiesMap has %d entities, want 0", len(rooms.EntitiesMap))
+	}
+	if found, ok := w.FindByName("hero"); !ok || found.ID() != player.ID() {
+		t.Errorf("FindByName(hero) = %v, %v, want the player", found.ID(), ok)
+	}
+}
+
+func TestRoomLifecycleUnloadCleansUp(t *testing.T) {
+	w := NewSystemsManager(1)
+	persistent := []Entity{
+		BuildEntityFromConfig(MustGetPreset("player")(48, 5, 4), w.NewEntityID(), 4),
+		BuildEntityFromConfig(MustGetPreset("heart")(48, 1, 14), w.NewEntityID(), 4),
+	}
+	w.AddEntities(persistent...)
+
+	RoomsMap = Rooms{
+		1: NewRoom("overworldFourWallsDoorsAllSides",
+			MustGetPreset("skull")(48, 3, 3),
+			MustGetPreset("warp")(48, 6, 6).With(WithWarpTo(2)),
+		),
+		2: NewRoom("overworldOpen"),
+	}
+	rooms := NewRoomLifecycle(&w, NewEntityByEntityIDMap(), map[EntityID]EntityConfig{}, NewMapStore("assets/tilemaps/", 48), DefaultConfig())
+
+	log := []string{}
+	rooms.OnLoad(func(roomID RoomID) {
+		log = append(log, fmt.Sprintf("load %d", roomID))
+	})
+	rooms.OnUnload(func(roomID RoomID) {
+		// The room's entities are still there while the hooks run
+		log = append(log, fmt.Sprintf("unload %d with %d entities", roomID, len(rooms.EntitiesMap)))
+	})
+
+	if err := rooms.Load(1); err != nil {
+		t.Fatal(err)
+	}
+	if len(rooms.EntitiesMap) != 2 || len(rooms.RoomWarps) != 1 {
+		t.Fatalf("loaded %d entities and %d warps, want 2 and 1", len(rooms.EntitiesMap), len(rooms.RoomWarps))
+	}
+	// Entities spawned while the room is loaded belong to it too
+	w.AddEntity(BuildEntityFromConfig(MustGetPreset("coin")(48, 7, 7), w.NewEntityID(), 4))
+	if got := len(w.Query(CategoryObstacle, 0)); got == 0 {
+		t.Fatal("the map spawned no obstacles")
+	}
+
+	rooms.Unload()
+
+	if got := rooms.Loaded(); got != 0 {
+		t.Errorf("Loaded = %d, want 0", got)
+	}
+	if len(rooms.EntitiesMap) != 0 || len(rooms.RoomWarps) != 0 {
+		t.Errorf("%d entities and %d warps are left, want none", len(rooms.EntitiesMap), len(rooms.RoomWarps))
+	}
+	if got, want := entityIDs(w.Query(0, 0)), entityIDs(persistent); !equalIDs(got, want) {
+		t.Errorf("entities after unload = %v, want only the persistent %v", got, want)
+	}
+
+	// Unloading again does nothing, and loading another room unloads first
+	rooms.Unload()
+	if err := rooms.Load(2); err != nil {
+		t.Fatal(err)
+	}
+	if err := rooms.Load(2); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"load 1", "unload 1 with 2 entities", "load 2", "unload 2 with 0 entities", "load 2"}
+	if strings.Join(log, ", ") != strings.Join(want, ", ") {
+		t.Errorf("hooks ran as %q, want %q", log, want)
+	}
+}
+
+func TestRoomLifecycleLoadUnknownRoom(t *testing.T) {
+	w := NewSystemsManager(1)
+	RoomsMap = Rooms{1: NewRoom("overworldOpen")}
+	rooms := NewRoomLifecycle(&w, NewEntityByEntityIDMap(), map[EntityID]EntityConfig{}, NewMapStore("assets/tilemaps/", 48), DefaultConfig())
+	if err := rooms.Load(1); err != nil {
+		t.Fatal(err)
+	}
+
+	err := rooms.Load(99)
+	if err == nil || !strings.Contains(err.Error(), "room 99 does not exist") {
+		t.Fatalf("Load err = %v, want the room to not exist", err)
+	}
+	if got := rooms.Loaded(); got != 0 {
+		t.Errorf("Loaded = %d, want 0", got)
+	}
+	if got := len(w.Query(0, 0)); got != 0 {
+		t.Errorf("%d entities are left, want none", got)
+	}
+}
+
+func TestRoomLifecyclePreloadsWarpDestinations(t *testing.T) {
+	// Room 1's map places warps to room 11; room 1 has no neighbours
+	RoomsMap = Rooms{
+		1:  NewRoom("rockWithCaveEntrance"),
+		5:  NewRoom("overworldOpen"),
+		11: NewRoom("dungeonFourDoors"),
+	}
+	w := NewSystemsManager(1)
+	maps := NewMapStore("assets/tilemaps/", 48)
+	maps.SetRooms(RoomsMap)
+	rooms := NewRoomLifecycle(&w, NewEntityByEntityIDMap(), map[EntityID]EntityConfig{}, maps, DefaultConfig())
+
+	if err := rooms.Load(1); err != nil {
+		t.Fatal(err)
+	}
+	if len(rooms.RoomWarps) != 2 {
+		t.Fatalf("got %d warps, want the 2 of the map", len(rooms.RoomWarps))
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for !maps.Loaded("dungeonFourDoors") {
+		if time.Now().After(deadline) {
+			t.Fatal("the map of the room the warps lead to was not preloaded")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestRoomLifecycleLoadSetsCollisionBounds(t *testing.T) {
+	RoomsMap = Rooms{
+		1: NewRoom("overworldOpen"),
+		2: NewRoom("arena"),
+	}
+	w := NewSystemsManager(1)
+	collision := NewSystemCollision(DefaultConfig().MapBoundsConfig(), &w)
+	w.AddSystem(SystemNameCollision, SystemPhaseCollision, &collision)
+	rooms := NewRoomLifecycle(&w, NewEntityByEntityIDMap(), map[EntityID]EntityConfig{}, NewMapStore("assets/tilemaps/", 48), DefaultConfig())
+
+	for _, tt := range []struct {
+		roomID RoomID
+		want   pixel.Rect
+	}{
+		{2, pixel.R(0, 0, 1344, 1152)},
+		{1, pixel.R(0, 0, 672, 576)},
+	} {
+		if err := rooms.Load(tt.roomID); err != nil {
+			t.Fatal(err)
+		}
+		if collision.MapBounds != tt.want {
+			t.Errorf("room %d: MapBounds = %v, want %v", tt.roomID, collision.MapBounds, tt.want)
+		}
+	}
+}
@@ -1,5 +1,7 @@
 package zelduh
 
+import "fmt"
+
 // RoomID is a room ID
 type RoomID int
 
@@ -21,6 +23,22 @@ type ConnectedRooms struct {
 	Left   RoomID
 }
 
+// Side returns the room connected to the side, or 0 if there is none
+func (c ConnectedRooms) Side(side Bound) RoomID {
+	switch side {
+	case BoundTop:
+		return c.Top
+	case BoundRight:
+		return c.Right
+	case BoundBottom:
+		return c.Bottom
+	case BoundLeft:
+		return c.Left
+	default:
+		return 0
+	}
+}
+
 // Room defines an API for room implementations
 type Roomer interface {
 	MapName() string
@@ -31,6 +49,19 @@ type Roomer interface {
 // Rooms is a type of map that indexes rooms by their ID
 type Rooms map[RoomID]Roomer
 
+// room returns the room with the given ID, or an error if there is no such room
+func (r Rooms) room(id RoomID) (*Room, error) {
+	roomer, ok := r[id]
+	if !ok {
+		return nil, fmt.Errorf("room %d does not exist", id)
+	}
+	room, ok := roomer.(*Room)
+	if !ok {
+		return nil, fmt.Errorf("room %d is a %T, not a *Room", id, roomer)
+	}
+	return room, nil
+}
+
 // Room represents one map section
 type Room struct {
 	mapName        string
@@ -5,30 +5,31 @@ import (
 	"image"
 	"math"
 	"os"
+	"sync"
+	"time"
 
 	"github.com/faiface/pixel"
 )
 
-func loadPicture(path string) pixel.Picture {
+func loadPicture(path string) (pixel.Picture, error) {
 	file, err := os.Open(path)
 	if err != nil {
-		fmt.Println("Could not open the picture:")
-		fmt.Println(err)
-		os.Exit(1)
+		return nil, err
 	}
 	defer file.Close()
 	img, _, err := image.Decode(file)
 	if err != nil {
-		fmt.Println("Could not decode the picture:")
-		fmt.Println(err)
-		os.Exit(1)
+		return nil, fmt.Errorf("%s: %w", path, err)
 	}
-	return pixel.PictureDataFromImage(img)
+	return pixel.PictureDataFromImage(img), nil
 }
 
 // LoadAndBuildSpritesheet this is a map of pixel engine sprites
-func LoadAndBuildSpritesheet(path string, tileSize float64) map[int]*pixel.Sprite {
-	pic := loadPicture(path)
+func LoadAndBuildSpritesheet(path string, tileSize float64) (map[int]*pixel.Sprite, error) {
+	pic, err := loadPicture(path)
+	if err != nil {
+		return nil, err
+	}
 
 	cols := pic.Bounds().W() / tileSize
 	rows := pic.Bounds().H() / tileSize
@@ -52,47 +53,92 @@ func LoadAndBuildSpritesheet(path string, tileSize float64) map[int]*pixel.Sprit
 			id--
 		}
 	}
+	return spritesheet, nil
+}
+
+// Spritesheet holds the sprites of a spritesheet by ID
+//
+// It may be read while it is loaded in the background; until it has loaded,
+// it has no sprites.
+type Spritesheet struct {
+	mu      sync.RWMutex
+	sprites map[int]*pixel.Sprite
+}
+
+// NewSpritesheet returns a Spritesheet holding the sprites
+func NewSpritesheet(sprites map[int]*pixel.Sprite) *Spritesheet {
+	return &Spritesheet{sprites: sprites}
+}
+
+// Sprite returns the sprite with the ID, and false if there is none
+func (s *Spritesheet) Sprite(id int) (*pixel.Sprite, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	sprite, ok := s.sprites[id]
+	return sprite, ok
+}
+
+// LoadSpritesheet loads and builds the spritesheet in the background, as a task
+// of the loader
+//
+// The returned Spritesheet is empty until the task is done.
+func LoadSpritesheet(loader *AssetLoader, path string, tileSize float64) *Spritesheet {
+	spritesheet := &Spritesheet{}
+	loader.Go(func() error {
+		sprites, err := LoadAndBuildSpritesheet(path, tileSize)
+		if err != nil {
+			return err
+		}
+		spritesheet.mu.Lock()
+		defer spritesheet.mu.Unlock()
+		spritesheet.sprites = sprites
+		return nil
+	})
 	return spritesheet
 }
 
-// GetSpriteSet returns a sprite set by key
-func GetSpriteSet(key string) []int {
-	return spriteSets[key]
+// GetAnimationClip returns an animation clip by key
+func GetAnimationClip(key string) AnimationClip {
+	return animationClips[key]
 }
 
-var spriteSets = map[string][]int{
-	"eyeburrower": []int{50, 50, 50, 91, 91, 91, 92, 92, 92, 93, 93, 93, 92, 92, 92},
-	"explosion": []int{
-		122, 122, 122,
-		123, 123, 123,
-		124, 124, 124,
-		125, 125, 125,
+var animationClips = map[string]AnimationClip{
+	"eyeburrower": {
+		Mode: AnimationLoop,
+		Frames: []AnimationFrame{
+			{SpriteID: 50, Duration: 300 * time.Millisecond},
+			{SpriteID: 91, Duration: 300 * time.Millisecond},
+			{SpriteID: 92, Duration: 300 * time.Millisecond},
+			{SpriteID: 93, Duration: 300 * time.Millisecond},
+			{SpriteID: 92, Duration: 300 * time.Millisecond},
+		},
 	},
-	"uiCoin":           []int{20},
-	"skeleton":         []int{31, 32},
-	"skull":            []int{36, 37, 38, 39},
-	"spinner":          []int{51, 52},
-	"puzzleBox":        []int{63},
-	"warpStone":        []int{61},
-	"playerUp":         []int{4, 195},
-	"playerRight":      []int{3, 194},
-	"playerDown":       []int{1, 192},
-	"playerLeft":       []int{2, 193},
-	"playerSwordUp":    []int{165},
-	"playerSwordRight": []int{164},
-	"playerSwordLeft":  []int{179},
-	"playerSwordDown":  []int{180},
-	"floorSwitch":      []int{112, 127},
-	"toggleObstacle":   []int{144, 114},
-	"swordUp":          []int{70},
-	"swordRight":       []int{67},
-	"swordDown":        []int{68},
-	"swordLeft":        []int{69},
-	"arrowUp":          []int{101},
-	"arrowRight":       []int{100},
-	"arrowDown":        []int{103},
-	"arrowLeft":        []int{102},
-	"bomb":             []int{138, 139, 140, 141},
-	"coin":             []int{5, 5, 6, 6, 21, 21},
-	"heart":            []int{106},
+	"explosion":        NewTimedAnimationClip(AnimationOnce, 100*time.Millisecond, 122, 123, 124, 125),
+	"uiCoin":           NewAnimationClip(AnimationLoop, 20),
+	"skeleton":         NewAnimationClip(AnimationLoop, 31, 32),
+	"skull":            NewAnimationClip(AnimationLoop, 36, 37, 38, 39),
+	"spinner":          NewAnimationClip(AnimationLoop, 51, 52),
+	"puzzleBox":        NewAnimationClip(AnimationLoop, 63),
+	"warpStone":        NewAnimationClip(AnimationLoop, 61),
+	"playerUp":         NewAnimationClip(AnimationLoop, 4, 195),
+	"playerRight":      NewAnimationClip(AnimationLoop, 3, 194),
+	"playerDown":       NewAnimationClip(AnimationLoop, 1, 192),
+	"playerLeft":       NewAnimationClip(AnimationLoop, 2, 193),
+	"playerSwordUp":    NewAnimationClip(AnimationLoop, 165),
+	"playerSwordRight": NewAnimationClip(AnimationLoop, 164),
+	"playerSwordLeft":  NewAnimationClip(AnimationLoop, 179),
+	"playerSwordDown":  NewAnimationClip(AnimationLoop, 180),
+	"floorSwitch":      NewAnimationClip(AnimationLoop, 112, 127),
+	"toggleObstacle":   NewAnimationClip(AnimationLoop, 144, 114),
+	"swordUp":          NewAnimationClip(AnimationLoop, 70),
+	"swordRight":       NewAnimationClip(AnimationLoop, 67),
+	"swordDown":        NewAnimationClip(AnimationLoop, 68),
+	"swordLeft":        NewAnimationClip(AnimationLoop, 69),
+	"arrowUp":          NewAnimationClip(AnimationLoop, 101),
+	"arrowRight":       NewAnimationClip(AnimationLoop, 100),
+	"arrowDown":        NewAnimationClip(AnimationLoop, 103),
+	"arrowLeft":        NewAnimationClip(AnimationLoop, 102),
+	"bomb":             NewAnimationClip(AnimationLoop, 138, 139, 140, 141),
+	"coin":             NewTimedAnimationClip(AnimationLoop, 200*time.Millisecond, 5, 6, 21),
+	"heart":            NewAnimationClip(AnimationLoop, 106),
 }
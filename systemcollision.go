@@ -6,36 +6,18 @@ import (
 	"github.com/faiface/pixel"
 )
 
-type collisionEntity struct {
-	ID EntityID
-	*ComponentSpatial
-	*ComponentInvincible
-}
-
 // SystemCollision is a custom system for detecting collisions and what to do when they occur
+//
+// Collisions are published as CollisionEvents on the SystemsManager's event bus.
 type SystemCollision struct {
-	MapBounds         pixel.Rect
-	player            collisionEntity
-	sword             collisionEntity
-	arrow             collisionEntity
-	enemies           []collisionEntity
-	coins             []collisionEntity
-	obstacles         []collisionEntity
-	moveableObstacles []collisionEntity
-	collisionSwitches []collisionEntity
-	warps             []collisionEntity
-	CollisionHandler  CollisionHandler
+	MapBounds      pixel.Rect
+	SystemsManager *SystemsManager
 }
 
+// NewSystemCollision returns a new SystemCollision
 func NewSystemCollision(
 	mapBoundsConfig MapBoundsConfig,
-	roomTransitionManager *RoomTransitionManager,
 	systemsManager *SystemsManager,
-	healthSystem *SystemHealth,
-	spatialSystem *SystemSpatial,
-	entitiesMap EntityByEntityID,
-	roomWarps map[EntityID]EntityConfig,
-	entities Entities,
 ) SystemCollision {
 	return SystemCollision{
 		MapBounds: pixel.R(
@@ -44,87 +26,29 @@ func NewSystemCollision(
 			mapBoundsConfig.Width,
 			mapBoundsConfig.Height,
 		),
-		CollisionHandler: CollisionHandler{
-			RoomTransitionManager: roomTransitionManager,
-			SystemsManager:        systemsManager,
-			HealthSystem:          healthSystem,
-			SpatialSystem:         spatialSystem,
-			EntitiesMap:           entitiesMap,
-			RoomWarps:             roomWarps,
-			Entities:              entities,
-		},
+		SystemsManager: systemsManager,
 	}
 }
 
-// AddEntity adds an entity to the system
-func (s *SystemCollision) AddEntity(entity Entity) {
-	r := collisionEntity{
-		ID:               entity.ID(),
-		ComponentSpatial: entity.ComponentSpatial,
-	}
-	switch entity.Category {
-	case CategoryPlayer:
-		s.player = r
-	case CategorySword:
-		s.sword = r
-	case CategoryArrow:
-		s.arrow = r
-	case CategoryMovableObstacle:
-		s.moveableObstacles = append(s.moveableObstacles, r)
-	case CategoryCollisionSwitch:
-		s.collisionSwitches = append(s.collisionSwitches, r)
-	case CategoryWarp:
-		s.warps = append(s.warps, r)
-	case CategoryEnemy:
-		r.ComponentInvincible = entity.ComponentInvincible
-		s.enemies = append(s.enemies, r)
-	case CategoryCoin:
-		s.coins = append(s.coins, r)
-	case CategoryObstacle:
-		s.obstacles = append(s.obstacles, r)
-	}
+func (s *SystemCollision) publish(kind CollisionKind, entityID, otherID EntityID) {
+	s.SystemsManager.Events.Publish(CollisionEvent{
+		Kind:     kind,
+		EntityID: entityID,
+		OtherID:  otherID,
+	})
 }
 
-// Remove removes the entity from the system
-func (s *SystemCollision) Remove(category EntityCategory, id EntityID) {
-	switch category {
-	case CategoryCoin:
-		for i := len(s.coins) - 1; i >= 0; i-- {
-			coin := s.coins[i]
-			if coin.ID == id {
-				s.coins = append(s.coins[:i], s.coins[i+1:]...)
-			}
-		}
-	case CategoryEnemy:
-		for i := len(s.enemies) - 1; i >= 0; i-- {
-			enemy := s.enemies[i]
-			if enemy.ID == id {
-				s.enemies = append(s.enemies[:i], s.enemies[i+1:]...)
-			}
-		}
-	}
+func (s *SystemCollision) publishBounds(entityID EntityID, side Bound) {
+	s.SystemsManager.Events.Publish(CollisionEvent{
+		Kind:     CollisionPlayerWithBounds,
+		EntityID: entityID,
+		Bound:    side,
+	})
 }
 
-// RemoveAll removes all entities from one category
-func (s *SystemCollision) RemoveAll(category EntityCategory) {
-	switch category {
-	case CategoryEnemy:
-		for i := len(s.enemies) - 1; i >= 0; i-- {
-			s.enemies = append(s.enemies[:i], s.enemies[i+1:]...)
-		}
-	case CategoryCollisionSwitch:
-		for i := len(s.collisionSwitches) - 1; i >= 0; i-- {
-			s.collisionSwitches = append(s.collisionSwitches[:i], s.collisionSwitches[i+1:]...)
-		}
-	case CategoryMovableObstacle:
-		for i := len(s.moveableObstacles) - 1; i >= 0; i-- {
-			s.moveableObstacles = append(s.moveableObstacles[:i], s.moveableObstacles[i+1:]...)
-		}
-	case CategoryObstacle:
-		for i := len(s.obstacles) - 1; i >= 0; i-- {
-			s.obstacles = append(s.obstacles[:i], s.obstacles[i+1:]...)
-		}
-	}
+// EntityQuery matches the entities SystemCollision checks
+func (s *SystemCollision) EntityQuery() (EntityCategory, ComponentType) {
+	return 0, ComponentTypeSpatial
 }
 
 func isColliding(r1, r2 pixel.Rect) bool {
@@ -135,68 +59,81 @@ func isColliding(r1, r2 pixel.Rect) bool {
 }
 
 // Update checks for collisions
-func (s *SystemCollision) Update() {
+func (s *SystemCollision) Update(dt float64) {
 
-	player := s.player
-	playerR := s.player.ComponentSpatial.Rect
+	player, ok := s.SystemsManager.First(CategoryPlayer, ComponentTypeSpatial)
+	if !ok {
+		return
+	}
+	// Rooms and states may have no sword or arrow; their checks are skipped
+	sword, hasSword := s.SystemsManager.First(CategorySword, ComponentTypeSpatial)
+	arrow, hasArrow := s.SystemsManager.First(CategoryArrow, ComponentTypeSpatial)
+	enemies := s.SystemsManager.Query(CategoryEnemy, ComponentTypeSpatial|ComponentTypeInvincible)
+	coins := s.SystemsManager.Query(CategoryCoin, ComponentTypeSpatial)
+	obstacles := s.SystemsManager.Query(CategoryObstacle, ComponentTypeSpatial)
+	moveableObstacles := s.SystemsManager.Query(CategoryMovableObstacle, ComponentTypeSpatial)
+	collisionSwitches := s.SystemsManager.Query(CategoryCollisionSwitch, ComponentTypeSpatial)
+	warps := s.SystemsManager.Query(CategoryWarp, ComponentTypeSpatial)
+
+	playerR := player.ComponentSpatial.Rect
 	mapBounds := s.MapBounds
 
 	// is player at map edge?
 	if player.ComponentSpatial.Rect.Min.Y <= mapBounds.Min.Y {
-		s.CollisionHandler.OnPlayerCollisionWithBounds(BoundBottom)
+		s.publishBounds(player.ID(), BoundBottom)
 	} else if player.ComponentSpatial.Rect.Min.X <= mapBounds.Min.X {
-		s.CollisionHandler.OnPlayerCollisionWithBounds(BoundLeft)
+		s.publishBounds(player.ID(), BoundLeft)
 	} else if player.ComponentSpatial.Rect.Max.X >= mapBounds.Max.X {
-		s.CollisionHandler.OnPlayerCollisionWithBounds(BoundRight)
+		s.publishBounds(player.ID(), BoundRight)
 	} else if player.ComponentSpatial.Rect.Max.Y >= mapBounds.Max.Y {
-		s.CollisionHandler.OnPlayerCollisionWithBounds(BoundTop)
+		s.publishBounds(player.ID(), BoundTop)
 	}
 
 	w, h := player.ComponentSpatial.Width, player.ComponentSpatial.Height
-	for _, enemy := range s.enemies {
+	for _, enemy := range enemies {
 		enemyR := enemy.ComponentSpatial.Rect
 
 		if isCircleCollision(
 			player.ComponentSpatial.HitBoxRadius,
 			enemy.ComponentSpatial.HitBoxRadius,
 			w, h, playerR, enemyR) {
-			s.CollisionHandler.OnPlayerCollisionWithEnemy(enemy.ID)
+			s.publish(CollisionPlayerWithEnemy, player.ID(), enemy.ID())
 		}
 
 		if !enemy.ComponentInvincible.Enabled {
-			if isCircleCollision(
-				s.sword.ComponentSpatial.HitBoxRadius,
+			if hasSword && isCircleCollision(
+				sword.ComponentSpatial.HitBoxRadius,
 				enemy.ComponentSpatial.HitBoxRadius,
-				w, h, s.sword.ComponentSpatial.Rect, enemyR) {
-				s.CollisionHandler.OnSwordCollisionWithEnemy(enemy.ID)
+				w, h, sword.ComponentSpatial.Rect, enemyR) {
+				s.publish(CollisionSwordWithEnemy, sword.ID(), enemy.ID())
 			}
 
-			if isCircleCollision(
-				s.arrow.ComponentSpatial.HitBoxRadius,
+			if hasArrow && isCircleCollision(
+				arrow.ComponentSpatial.HitBoxRadius,
 				enemy.ComponentSpatial.HitBoxRadius,
-				w, h, s.arrow.ComponentSpatial.Rect, enemyR) {
-				s.CollisionHandler.OnArrowCollisionWithEnemy(enemy.ID)
+				w, h, arrow.ComponentSpatial.Rect, enemyR) {
+				s.publish(CollisionArrowWithEnemy, arrow.ID(), enemy.ID())
 			}
 		}
 	}
-	for _, coin := range s.coins {
-		if isColliding(coin.ComponentSpatial.Rect, s.player.ComponentSpatial.Rect) {
-			s.CollisionHandler.OnPlayerCollisionWithCoin(coin.ID)
+	for _, coin := range coins {
+		if isColliding(coin.ComponentSpatial.Rect, player.ComponentSpatial.Rect) {
+			s.publish(CollisionPlayerWithCoin, player.ID(), coin.ID())
 		}
 	}
 
-	for _, obstacle := range s.obstacles {
+	for _, obstacle := range obstacles {
 		mod := player.ComponentSpatial.CollisionWithRectMod
 		if isColliding(obstacle.ComponentSpatial.Rect, pixel.R(
-			s.player.ComponentSpatial.Rect.Min.X+mod,
-			s.player.ComponentSpatial.Rect.Min.Y+mod,
-			s.player.ComponentSpatial.Rect.Max.X-mod,
-			s.player.ComponentSpatial.Rect.Max.Y-mod,
+			player.ComponentSpatial.Rect.Min.X+mod,
+			player.ComponentSpatial.Rect.Min.Y+mod,
+			player.ComponentSpatial.Rect.Max.X-mod,
+			player.ComponentSpatial.Rect.Max.Y-mod,
 		)) {
-			s.CollisionHandler.OnPlayerCollisionWithObstacle(obstacle.ID)
+			s.publish(CollisionPlayerWithObstacle, player.ID(), obstacle.ID())
 		}
 
-		for _, enemy := range s.enemies {
+		for _, enemy := range enemies {
 			mod = enemy.ComponentSpatial.CollisionWithRectMod
 			if isColliding(obstacle.ComponentSpatial.Rect, pixel.R(
 				enemy.ComponentSpatial.Rect.Min.X+mod,
@@ -204,67 +141,55 @@ func (s *SystemCollision) Update() {
 				enemy.ComponentSpatial.Rect.Max.X-mod,
 				enemy.ComponentSpatial.Rect.Max.Y-mod,
 			)) {
-				s.CollisionHandler.OnEnemyCollisionWithObstacle(enemy.ID, obstacle.ID)
+				s.publish(CollisionEnemyWithObstacle, enemy.ID(), obstacle.ID())
 			}
 		}
 
-		if isColliding(obstacle.ComponentSpatial.Rect, s.arrow.ComponentSpatial.Rect) {
-			s.CollisionHandler.OnArrowCollisionWithObstacle()
+		if hasArrow && isColliding(obstacle.ComponentSpatial.Rect, arrow.ComponentSpatial.Rect) {
+			s.publish(CollisionArrowWithObstacle, arrow.ID(), obstacle.ID())
 		}
 	}
-	for _, moveableObstacle := range s.moveableObstacles {
-		if isColliding(moveableObstacle.ComponentSpatial.Rect, s.player.ComponentSpatial.Rect) {
-			s.CollisionHandler.OnPlayerCollisionWithMoveableObstacle(moveableObstacle.ID)
+	for _, moveableObstacle := range moveableObstacles {
+		if isColliding(moveableObstacle.ComponentSpatial.Rect, player.ComponentSpatial.Rect) {
+			s.publish(CollisionPlayerWithMoveableObstacle, player.ID(), moveableObstacle.ID())
 		}
 
-		for _, collisionSwitch := range s.collisionSwitches {
+		for _, collisionSwitch := range collisionSwitches {
 			if isColliding(moveableObstacle.ComponentSpatial.Rect, collisionSwitch.ComponentSpatial.Rect) {
-				s.CollisionHandler.OnMoveableObstacleCollisionWithSwitch(collisionSwitch.ID)
+				s.publish(CollisionMoveableObstacleWithSwitch, moveableObstacle.ID(), collisionSwitch.ID())
 			} else {
-				s.CollisionHandler.OnMoveableObstacleNoCollisionWithSwitch(collisionSwitch.ID)
-			}
-		}
-
-		for _, enemy := range s.enemies {
-			if isColliding(moveableObstacle.ComponentSpatial.Rect, enemy.ComponentSpatial.Rect) {
-				// s.EnemyCollisionWithMoveableObstacle(enemy.ID)
-			}
-		}
-
-		for _, obstacle := range s.obstacles {
-			if isColliding(moveableObstacle.ComponentSpatial.Rect, obstacle.ComponentSpatial.Rect) {
-				// s.MoveableObstacleCollisionWithObstacle(moveableObstacle.ID)
+				s.publish(CollisionMoveableObstacleNoSwitch, moveableObstacle.ID(), collisionSwitch.ID())
 			}
 		}
 
-		if isColliding(moveableObstacle.ComponentSpatial.Rect, s.arrow.ComponentSpatial.Rect) {
-			s.CollisionHandler.OnArrowCollisionWithObstacle()
+		if hasArrow && isColliding(moveableObstacle.ComponentSpatial.Rect, arrow.ComponentSpatial.Rect) {
+			s.publish(CollisionArrowWithObstacle, arrow.ID(), moveableObstacle.ID())
 		}
 	}
 
-	for _, collisionSwitch := range s.collisionSwitches {
+	for _, collisionSwitch := range collisionSwitches {
 		if collisionSwitch.ComponentSpatial.HitBoxRadius > 0 {
 			if isCircleCollision(
-				s.player.ComponentSpatial.HitBoxRadius,
+				player.ComponentSpatial.HitBoxRadius,
 				collisionSwitch.ComponentSpatial.HitBoxRadius,
-				w, h, s.player.ComponentSpatial.Rect, collisionSwitch.ComponentSpatial.Rect) {
-				s.CollisionHandler.OnPlayerCollisionWithSwitch(collisionSwitch.ID)
+				w, h, player.ComponentSpatial.Rect, collisionSwitch.ComponentSpatial.Rect) {
+				s.publish(CollisionPlayerWithSwitch, player.ID(), collisionSwitch.ID())
 			} else {
-				s.CollisionHandler.OnPlayerNoCollisionWithSwitch(collisionSwitch.ID)
+				s.publish(CollisionPlayerNoSwitch, player.ID(), collisionSwitch.ID())
 			}
 		} else {
-			if isColliding(s.player.ComponentSpatial.Rect, collisionSwitch.ComponentSpatial.Rect) {
-				s.CollisionHandler.OnPlayerCollisionWithSwitch(collisionSwitch.ID)
+			if isColliding(player.ComponentSpatial.Rect, collisionSwitch.ComponentSpatial.Rect) {
+				s.publish(CollisionPlayerWithSwitch, player.ID(), collisionSwitch.ID())
 			} else {
-				s.CollisionHandler.OnPlayerNoCollisionWithSwitch(collisionSwitch.ID)
+				s.publish(CollisionPlayerNoSwitch, player.ID(), collisionSwitch.ID())
 			}
 		}
 
 	}
 
-	for _, warp := range s.warps {
-		if isColliding(s.player.ComponentSpatial.Rect, warp.ComponentSpatial.Rect) {
-			s.CollisionHandler.OnPlayerCollisionWithWarp(warp.ID)
+	for _, warp := range warps {
+		if isColliding(player.ComponentSpatial.Rect, warp.ComponentSpatial.Rect) {
+			s.publish(CollisionPlayerWithWarp, player.ID(), warp.ID())
 		}
 	}
 }
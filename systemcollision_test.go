@@ -0,0 +1,31 @@
+package zelduh
+
+import "testing"
+
+func TestSystemCollisionWithoutSwordOrArrow(t *testing.T) {
+	w := NewSystemsManager(1)
+	player := BuildEntityFromConfig(MustGetPreset("player")(48, 5, 4), w.NewEntityID(), 4)
+	w.AddEntity(player)
+	w.AddEntity(BuildEntityFromConfig(MustGetPreset("skull")(48, 5, 4), w.NewEntityID(), 4))
+	w.AddEntity(BuildEntityFromConfig(MustGetPreset("obstacle")(48, 5, 4), w.NewEntityID(), 4))
+
+	kinds := []CollisionKind{}
+	w.Events.Subscribe(EventTypeCollision, func(e Event) {
+		kinds = append(kinds, e.(CollisionEvent).Kind)
+	})
+	collision := NewSystemCollision(DefaultConfig().MapBoundsConfig(), &w)
+	w.AddSystem(SystemNameCollision, SystemPhaseCollision, &collision)
+
+	w.tick(testDt)
+
+	want := map[CollisionKind]bool{CollisionPlayerWithEnemy: true, CollisionPlayerWithObstacle: true, CollisionEnemyWithObstacle: true}
+	for _, kind := range kinds {
+		if !want[kind] {
+			t.Errorf("unexpected collision %v", kind)
+		}
+		delete(want, kind)
+	}
+	for kind := range want {
+		t.Errorf("missing collision %v", kind)
+	}
+}
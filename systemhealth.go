@@ -1,34 +1,30 @@
 package zelduh
 
-type healthEntity struct {
-	ID EntityID
-	*ComponentHealth
-}
-
 // SystemHealth is a custom system for altering character health
 type SystemHealth struct {
-	entities []healthEntity
+	SystemsManager *SystemsManager
 }
 
-// AddEntity adds the entity to the system
-func (s *SystemHealth) AddEntity(entity Entity) {
-	s.entities = append(s.entities, healthEntity{
-		ID:              entity.ID(),
-		ComponentHealth: entity.ComponentHealth,
-	})
+// EntityQuery matches the entities with health
+func (s *SystemHealth) EntityQuery() (EntityCategory, ComponentType) {
+	return 0, ComponentTypeHealth
 }
 
 // Hit reduces entity health by d
 func (s *SystemHealth) Hit(entityID EntityID, d int) bool {
-	for i := 0; i < len(s.entities); i++ {
-		entity := s.entities[i]
-		if entity.ID == entityID {
-			entity.ComponentHealth.Total -= d
-			return entity.ComponentHealth.Total == 0
-		}
+	entity, ok := s.SystemsManager.Entity(entityID)
+	if !ok || entity.ComponentHealth == nil {
+		return false
 	}
-	return false
+	entity.ComponentHealth.Total -= d
+	return entity.ComponentHealth.Total == 0
+}
+
+// Alive returns true if the entity exists and has health left
+func (s *SystemHealth) Alive(entityID EntityID) bool {
+	entity, ok := s.SystemsManager.Entity(entityID)
+	return ok && entity.ComponentHealth != nil && entity.ComponentHealth.Total > 0
 }
 
 // Update is a no-op
-func (s *SystemHealth) Update() {}
+func (s *SystemHealth) Update(dt float64) {}
@@ -4,24 +4,20 @@ import (
 	"github.com/faiface/pixel/pixelgl"
 )
 
-type inputEntity struct {
-	*ComponentMovement
-	*ComponentIgnore
-	*ComponentDash
-}
-
 // SystemInput is a custom system for detecting collisions and what to do when they occur
 type SystemInput struct {
-	Win           *pixelgl.Window
-	playerEntity  inputEntity
-	playerEnabled bool
-	sword         inputEntity
-	arrow         inputEntity
+	Win            InputSource
+	SystemsManager *SystemsManager
+	playerEnabled  bool
 }
 
-// DisablePlayer disables player input
+// DisablePlayer disables player input, and stops the player where it is
 func (s *SystemInput) DisablePlayer() {
 	s.playerEnabled = false
+	if player, ok := s.SystemsManager.First(CategoryPlayer, ComponentTypeMovement|ComponentTypeDash); ok {
+		player.ComponentMovement.Speed = 0
+		player.ComponentDash.Charge = 0
+	}
 }
 
 // EnablePlayer enables player input
@@ -29,31 +25,26 @@ func (s *SystemInput) EnablePlayer() {
 	s.playerEnabled = true
 }
 
-// AddEntity adds an entity to the system
-func (s *SystemInput) AddEntity(entity Entity) {
-	r := inputEntity{
-		ComponentMovement: entity.ComponentMovement,
-		ComponentDash:     entity.ComponentDash,
-		ComponentIgnore:   entity.ComponentIgnore,
-	}
-	switch entity.Category {
-	case CategoryPlayer:
-		s.playerEntity = r
-	case CategorySword:
-		s.sword = r
-	case CategoryArrow:
-		s.arrow = r
-	}
+// EntityQuery matches the player, which SystemInput controls
+func (s SystemInput) EntityQuery() (EntityCategory, ComponentType) {
+	return CategoryPlayer, ComponentTypeMovement | ComponentTypeDash
 }
 
 // Update checks for player input
-func (s SystemInput) Update() {
+func (s SystemInput) Update(dt float64) {
 	if !s.playerEnabled {
 		return
 	}
 
+	player, ok := s.SystemsManager.First(CategoryPlayer, ComponentTypeMovement|ComponentTypeDash)
+	if !ok {
+		return
+	}
+	// The player may have no sword or arrow; their controls are skipped
+	sword, hasSword := s.SystemsManager.First(CategorySword, ComponentTypeIgnore)
+	arrow, hasArrow := s.SystemsManager.First(CategoryArrow, ComponentTypeMovement|ComponentTypeIgnore|ComponentTypeAttachment)
+
 	win := s.Win
-	player := s.playerEntity
 
 	movingSpeed := player.ComponentMovement.MaxSpeed
 
@@ -75,42 +66,52 @@ func (s SystemInput) Update() {
 	}
 
 	// attack with sword
-	s.sword.ComponentMovement.Direction = player.ComponentMovement.Direction
-	if win.Pressed(pixelgl.KeyF) {
-		s.sword.ComponentMovement.Speed = 1.0
-		s.sword.ComponentIgnore.Value = false
-	} else {
-		s.sword.ComponentMovement.Speed = 0
-		s.sword.ComponentIgnore.Value = true
+	if hasSword {
+		sword.ComponentIgnore.Value = !win.Pressed(pixelgl.KeyF)
 	}
 
 	// fire arrow
-	if s.arrow.ComponentMovement.RemainingMoves == 0 {
-		s.arrow.ComponentMovement.Direction = player.ComponentMovement.Direction
-		if win.Pressed(pixelgl.KeyG) {
-			s.arrow.ComponentMovement.Speed = 7.0
-			s.arrow.ComponentMovement.RemainingMoves = 100
-			s.arrow.ComponentIgnore.Value = false
+	if hasArrow {
+		if arrow.ComponentMovement.RemainingMoves == 0 {
+			arrow.ComponentMovement.Direction = player.ComponentMovement.Direction
+			if win.Pressed(pixelgl.KeyG) {
+				arrow.ComponentMovement.Speed = 420.0
+				arrow.ComponentMovement.RemainingMoves = 100
+				arrow.ComponentIgnore.Value = false
+				arrow.ComponentAttachment.Detach()
+			} else {
+				arrow.ComponentMovement.Speed = 0
+				arrow.ComponentMovement.RemainingMoves = 0
+				arrow.ComponentIgnore.Value = true
+				arrow.ComponentAttachment.Attach(player.ID())
+			}
 		} else {
-			s.arrow.ComponentMovement.Speed = 0
-			s.arrow.ComponentMovement.RemainingMoves = 0
-			s.arrow.ComponentIgnore.Value = true
+			arrow.ComponentMovement.RemainingMoves--
 		}
-	} else {
-		s.arrow.ComponentMovement.RemainingMoves--
 	}
 
 	// dashing
 	if !win.Pressed(pixelgl.KeyF) && win.Pressed(pixelgl.KeySpace) {
-		if s.playerEntity.ComponentDash.Charge < s.playerEntity.ComponentDash.MaxCharge {
-			s.playerEntity.ComponentDash.Charge++
-			s.sword.ComponentMovement.Speed = 0
-			s.sword.ComponentIgnore.Value = true
-		} else {
-			s.sword.ComponentMovement.Speed = 1.0
-			s.sword.ComponentIgnore.Value = false
+		if player.ComponentDash.Charge < player.ComponentDash.MaxCharge {
+			player.ComponentDash.Charge++
+			if hasSword {
+				sword.ComponentIgnore.Value = true
+			}
+		} else if hasSword {
+			sword.ComponentIgnore.Value = false
 		}
 	} else {
-		s.playerEntity.ComponentDash.Charge = 0
+		player.ComponentDash.Charge = 0
+	}
+
+	// The player is shown attacking while the sword or arrow is out
+	if animation := player.ComponentAnimation; animation != nil {
+		swordOut := hasSword && !sword.ComponentIgnore.Value
+		arrowOut := hasArrow && !arrow.ComponentIgnore.Value
+		if swordOut || arrowOut {
+			animation.Key = attackDirectionAnimation(player.ComponentMovement.Direction)
+		} else {
+			animation.Key = ""
+		}
 	}
 }
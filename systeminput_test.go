@@ -0,0 +1,32 @@
+package zelduh
+
+import (
+	"testing"
+
+	"github.com/faiface/pixel/pixelgl"
+)
+
+func TestSystemInputWithoutSwordOrArrow(t *testing.T) {
+	w := NewSystemsManager(1)
+	player := BuildEntityFromConfig(MustGetPreset("player")(48, 5, 4), w.NewEntityID(), 4)
+	w.AddEntity(player)
+	ui, window := NewHeadlessUI(DefaultConfig().WindowConfig())
+	input := &SystemInput{Win: ui.Window, SystemsManager: &w}
+	input.EnablePlayer()
+	w.AddSystem(SystemNameInput, SystemPhaseInput, input)
+
+	// Attack, fire and dash, with nothing to attack or fire with
+	for _, button := range []pixelgl.Button{pixelgl.KeyRight, pixelgl.KeyF, pixelgl.KeyG, pixelgl.KeySpace} {
+		window.Press(button)
+		window.Update()
+		w.tick(testDt)
+		window.Release(button)
+	}
+
+	if got := player.ComponentMovement.Direction; got != DirectionRight {
+		t.Errorf("Direction = %v, want %v", got, DirectionRight)
+	}
+	if got := player.ComponentAnimation.Key; got != "" {
+		t.Errorf("animation key = %q, want none without a sword or arrow", got)
+	}
+}
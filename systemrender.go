@@ -2,294 +2,165 @@ package zelduh
 
 import (
 	"github.com/faiface/pixel"
-	"github.com/faiface/pixel/pixelgl"
 )
 
-type renderEntity struct {
-	ID       EntityID
-	Category EntityCategory
-	*ComponentSpatial
-	*ComponentAppearance
-	*ComponentAnimation
-	*ComponentMovement
-	*ComponentIgnore
-	*ComponentToggler
-	*ComponentTemporary
-}
-
 // SystemRender is a custom system
+//
+// Entities are positioned in room coordinates, and View transforms them to the
+// screen. HUD entities are positioned in screen coordinates and drawn as is.
+//
+// Mask, if set, is called after the room's entities are drawn and before the
+// HUD, to hide the parts of a room that are outside the map on screen.
 type SystemRender struct {
-	Win         *pixelgl.Window
-	Spritesheet map[int]*pixel.Sprite
-
-	player renderEntity
-	arrow  renderEntity
-	sword  renderEntity
+	Win            Renderer
+	Spritesheet    *Spritesheet
+	SystemsManager *SystemsManager
+	View           pixel.Matrix
+	Mask           func()
+}
 
-	entities  []renderEntity
-	obstacles []renderEntity
+// renderable pairs an entity with the animation it is currently showing
+type renderable struct {
+	entity   Entity
+	animData *ComponentAnimationData
 }
 
-// AddEntity adds an entity to the system
-func (s *SystemRender) AddEntity(entity Entity) {
-	r := renderEntity{
-		ID:                 entity.ID(),
-		Category:           entity.Category,
-		ComponentSpatial:   entity.ComponentSpatial,
-		ComponentAnimation: entity.ComponentAnimation,
-		ComponentMovement:  entity.ComponentMovement,
-		ComponentTemporary: entity.ComponentTemporary,
-		ComponentIgnore:    entity.ComponentIgnore,
-	}
-	switch entity.Category {
-	case CategoryPlayer:
-		s.player = r
-	case CategoryArrow:
-		s.arrow = r
-	case CategorySword:
-		s.sword = r
-	case CategoryExplosion:
-		fallthrough
-	case CategoryHeart:
-		fallthrough
-	case CategoryEnemy:
-		fallthrough
-	case CategoryCollisionSwitch:
-		fallthrough
-	case CategoryMovableObstacle:
-		fallthrough
-	case CategoryWarp:
-		fallthrough
-	case CategoryCoin:
-		fallthrough
-	default:
-		if entity.ComponentToggler != nil {
-			r.ComponentToggler = entity.ComponentToggler
-		}
-		s.entities = append(s.entities, r)
-	}
+// EntityQuery matches the entities SystemRender may draw
+func (s *SystemRender) EntityQuery() (EntityCategory, ComponentType) {
+	return 0, ComponentTypeSpatial | ComponentTypeAnimation
 }
 
-// RemoveAll removes all entities from one category
-func (s *SystemRender) RemoveAll(category EntityCategory) {
-	switch category {
-	case CategoryEnemy:
-		for i := len(s.entities) - 1; i >= 0; i-- {
-			if s.entities[i].Category == CategoryEnemy {
-				s.entities = append(s.entities[:i], s.entities[i+1:]...)
-			}
+// Update advances animations by one tick
+func (s *SystemRender) Update(dt float64) {
+	for _, r := range s.renderables() {
+		if r.entity.ComponentToggler == nil {
+			r.animData.Advance(dt)
 		}
 	}
 }
 
-// RemoveEntity removes an entity by ID
-func (s *SystemRender) RemoveEntity(id EntityID) {
-	for i := len(s.entities) - 1; i >= 0; i-- {
-		if s.entities[i].ID == id {
-			s.entities = append(s.entities[:i], s.entities[i+1:]...)
+// Draw draws every visible entity, interpolating its position between the last
+// two ticks, with the HUD on top
+func (s *SystemRender) Draw(alpha float64) {
+	renderables := s.renderables()
+	for _, r := range renderables {
+		if !r.entity.HUD {
+			s.draw(r, alpha)
 		}
 	}
-}
-
-// RemoveAllEntities removes all entities
-func (s *SystemRender) RemoveAllEntities() {
-	for i := len(s.entities) - 1; i >= 0; i-- {
-		s.entities = append(s.entities[:i], s.entities[i+1:]...)
+	if s.Mask != nil {
+		s.Mask()
 	}
-}
-
-// Update changes spatial data based on movement data
-func (s *SystemRender) Update() {
-
-	for _, entity := range s.entities {
-		if entity.ComponentIgnore != nil && !entity.ComponentIgnore.Value {
-			if entity.ComponentTemporary != nil {
-				if entity.ComponentTemporary.Expiration == 0 {
-					entity.ComponentTemporary.OnExpiration()
-					s.RemoveEntity(entity.ID)
-				} else {
-					entity.ComponentTemporary.Expiration--
-				}
-			}
-			if entity.ComponentToggler != nil {
-				s.animateToggleFrame(entity)
-			} else {
-				s.animateDefault(entity)
-			}
+	for _, r := range renderables {
+		if r.entity.HUD {
+			s.draw(r, alpha)
 		}
 	}
+}
 
-	player := s.player
-	arrow := s.arrow
-	sword := s.sword
-
-	if !sword.ComponentIgnore.Value {
-		s.animateDirections(player.ComponentMovement.Direction, sword)
+func (s *SystemRender) draw(r renderable, alpha float64) {
+	var frameIndex int
+	if toggler := r.entity.ComponentToggler; toggler != nil {
+		if !toggler.Enabled() {
+			frameIndex = r.animData.Frames[0]
+		} else {
+			frameIndex = r.animData.Frames[1]
+		}
+	} else {
+		frameIndex = r.animData.Frames[r.animData.Frame]
 	}
-
-	if !arrow.ComponentIgnore.Value {
-		s.animateDirections(player.ComponentMovement.Direction, arrow)
+	frame, ok := s.Spritesheet.Sprite(frameIndex)
+	if !ok {
+		return
 	}
 
-	if sword.ComponentIgnore != nil && sword.ComponentIgnore.Value && arrow.ComponentIgnore.Value {
-		s.animateDirections(player.ComponentMovement.Direction, player)
-	} else {
-		s.animateAttackDirection(player.ComponentMovement.Direction, player)
+	spatial := r.entity.ComponentSpatial
+	min := pixel.Lerp(spatial.PrevRect.Min, spatial.Rect.Min, alpha)
+	v := pixel.V(
+		min.X+spatial.Width/2,
+		min.Y+spatial.Height/2,
+	)
+	matrix := pixel.IM.Moved(v)
+	if !r.entity.HUD {
+		matrix = matrix.Chained(s.View)
 	}
-
+	frame.Draw(s.Win, matrix)
 }
 
-func (s *SystemRender) animateToggleFrame(entity renderEntity) {
-	if anim := entity.ComponentAnimation; anim != nil {
-		if animData := anim.Map["default"]; animData != nil {
-			var frameIndex int
-			if !entity.ComponentToggler.Enabled() {
-				frameIndex = animData.Frames[0]
-			} else {
-				frameIndex = animData.Frames[1]
-			}
-			frame := s.Spritesheet[frameIndex]
-
-			v := pixel.V(
-				entity.ComponentSpatial.Rect.Min.X+entity.ComponentSpatial.Width/2,
-				entity.ComponentSpatial.Rect.Min.Y+entity.ComponentSpatial.Height/2,
-			)
-			frame.Draw(s.Win, pixel.IM.Moved(v))
+// renderables returns the entities to draw, in draw order, with the animation each is showing
+//
+// Entities that can be attached to another, such as the sword and arrow, are
+// drawn over the rest of the room, and the entities they are attached to, such
+// as the player, over them.
+func (s *SystemRender) renderables() []renderable {
+	carriers := map[EntityID]bool{}
+	for _, entity := range s.SystemsManager.Query(0, ComponentTypeAttachment) {
+		if parent, ok := entity.ComponentAttachment.Parent(); ok {
+			carriers[parent] = true
 		}
 	}
-}
-
-func (s *SystemRender) animateDefault(entity renderEntity) {
-	if anim := entity.ComponentAnimation; anim != nil {
-		if animData := anim.Map["default"]; animData != nil {
-			rate := animData.FrameRateCount
-			if rate < animData.FrameRate {
-				rate++
-			} else {
-				rate = 0
-			}
-			animData.FrameRateCount = rate
-
-			frameNum := animData.Frame
-			if rate == animData.FrameRate {
-				if frameNum < len(animData.Frames)-1 {
-					frameNum++
-				} else {
-					frameNum = 0
-				}
-				animData.Frame = frameNum
-			}
 
-			frameIndex := animData.Frames[frameNum]
-			frame := s.Spritesheet[frameIndex]
-
-			v := pixel.V(
-				entity.ComponentSpatial.Rect.Min.X+entity.ComponentSpatial.Width/2,
-				entity.ComponentSpatial.Rect.Min.Y+entity.ComponentSpatial.Height/2,
-			)
-			frame.Draw(s.Win, pixel.IM.Moved(v))
-		}
-	}
-}
-
-func (s *SystemRender) animateAttackDirection(dir Direction, entity renderEntity) {
-	if anim := entity.ComponentAnimation; anim != nil {
-		var animData *ComponentAnimationData
-		switch dir {
-		case DirectionUp:
-			animData = anim.Map["swordAttackUp"]
-		case DirectionRight:
-			animData = anim.Map["swordAttackRight"]
-		case DirectionDown:
-			animData = anim.Map["swordAttackDown"]
-		case DirectionLeft:
-			animData = anim.Map["swordAttackLeft"]
+	var room, attachable, carrying []renderable
+	for _, entity := range s.SystemsManager.Query(0, ComponentTypeSpatial|ComponentTypeIgnore|ComponentTypeAnimation) {
+		if entity.ComponentIgnore.Value {
+			continue
 		}
-
-		rate := animData.FrameRateCount
-		if rate < animData.FrameRate {
-			rate++
-		} else {
-			rate = 0
+		animData := entity.ComponentAnimation.Map[animationKey(entity)]
+		if animData == nil {
+			continue
 		}
-		animData.FrameRateCount = rate
-
-		frameNum := animData.Frame
-		if rate == animData.FrameRate {
-			if frameNum < len(animData.Frames)-1 {
-				frameNum++
-			} else {
-				frameNum = 0
-			}
-			animData.Frame = frameNum
+		r := renderable{entity, animData}
+		switch {
+		case carriers[entity.ID()]:
+			carrying = append(carrying, r)
+		case entity.ComponentAttachment != nil:
+			attachable = append(attachable, r)
+		default:
+			room = append(room, r)
 		}
-
-		frameIndex := animData.Frames[frameNum]
-		frame := s.Spritesheet[frameIndex]
-
-		rect := entity.ComponentSpatial.Rect
-		v := pixel.V(
-			rect.Min.X+entity.ComponentSpatial.Width/2,
-			rect.Min.Y+entity.ComponentSpatial.Height/2,
-		)
-
-		frame.Draw(s.Win, pixel.IM.Moved(v))
 	}
-}
 
-func (s *SystemRender) animateDirections(dir Direction, entity renderEntity) {
-	// if entity.ComponentSpatial.HitBoxRadius > 0 {
-	// 	shape := entity.ComponentSpatial.Shape
-	// 	shape.Clear()
-	// 	shape.Color = colornames.Yellow
-	// 	shape.Push(pixel.V(
-	// 		entity.ComponentSpatial.Rect.Min.X+entity.ComponentSpatial.Width/2,
-	// 		entity.ComponentSpatial.Rect.Min.Y+entity.ComponentSpatial.Height/2,
-	// 	))
-	// 	// s.Push(entity.ComponentSpatial.Rect.Max)
-	// 	shape.Circle(entity.ComponentSpatial.HitBoxRadius, 0)
-	// 	shape.Draw(s.Win)
-	// }
-	if anim := entity.ComponentAnimation; anim != nil {
-		var animData *ComponentAnimationData
-		switch dir {
-		case DirectionUp:
-			animData = anim.Map["up"]
-		case DirectionRight:
-			animData = anim.Map["right"]
-		case DirectionDown:
-			animData = anim.Map["down"]
-		case DirectionLeft:
-			animData = anim.Map["left"]
-		}
-
-		rate := animData.FrameRateCount
-		if rate < animData.FrameRate {
-			rate++
-		} else {
-			rate = 0
-		}
-		animData.FrameRateCount = rate
+	all := append(room, attachable...)
+	return append(all, carrying...)
+}
 
-		frameNum := animData.Frame
-		if rate == animData.FrameRate {
-			if frameNum < len(animData.Frames)-1 {
-				frameNum++
-			} else {
-				frameNum = 0
-			}
-			animData.Frame = frameNum
+// animationKey returns the name of the clip the entity shows
+func animationKey(entity Entity) string {
+	if key := entity.ComponentAnimation.Key; key != "" {
+		return key
+	}
+	if movement := entity.ComponentMovement; movement != nil {
+		if key := directionAnimation(movement.Direction); entity.ComponentAnimation.Map[key] != nil {
+			return key
 		}
+	}
+	return "default"
+}
 
-		frameIndex := animData.Frames[frameNum]
-		frame := s.Spritesheet[frameIndex]
-
-		rect := entity.ComponentSpatial.Rect
-		v := pixel.V(
-			rect.Min.X+entity.ComponentSpatial.Width/2,
-			rect.Min.Y+entity.ComponentSpatial.Height/2,
-		)
+func directionAnimation(dir Direction) string {
+	switch dir {
+	case DirectionUp:
+		return "up"
+	case DirectionRight:
+		return "right"
+	case DirectionDown:
+		return "down"
+	case DirectionLeft:
+		return "left"
+	}
+	return ""
+}
 
-		frame.Draw(s.Win, pixel.IM.Moved(v))
+func attackDirectionAnimation(dir Direction) string {
+	switch dir {
+	case DirectionUp:
+		return "swordAttackUp"
+	case DirectionRight:
+		return "swordAttackRight"
+	case DirectionDown:
+		return "swordAttackDown"
+	case DirectionLeft:
+		return "swordAttackLeft"
 	}
+	return ""
 }
@@ -0,0 +1,55 @@
+package zelduh
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSystemRenderOrderAndClips(t *testing.T) {
+	w := NewSystemsManager(1)
+	tileSize := 48.0
+	player := BuildEntityFromConfig(MustGetPreset("player")(tileSize, 5, 4), w.NewEntityID(), 5)
+	sword := BuildEntityFromConfig(MustGetPreset("sword")(tileSize, 0, 0), w.NewEntityID(), 5)
+	arrow := BuildEntityFromConfig(MustGetPreset("arrow")(tileSize, 0, 0), w.NewEntityID(), 5)
+	skull := BuildEntityFromConfig(MustGetPreset("skull")(tileSize, 2, 2), w.NewEntityID(), 5)
+	sword.ComponentAttachment.Attach(player.ID())
+	arrow.ComponentAttachment.Attach(player.ID())
+	w.AddEntities(player, sword, arrow, skull)
+	render := &SystemRender{SystemsManager: &w}
+
+	type drawn struct {
+		id  EntityID
+		key string
+	}
+	draws := func() []drawn {
+		result := []drawn{}
+		for _, r := range render.renderables() {
+			var key string
+			for k, animData := range r.entity.ComponentAnimation.Map {
+				if animData == r.animData {
+					key = k
+				}
+			}
+			result = append(result, drawn{r.entity.ID(), key})
+		}
+		return result
+	}
+
+	player.ComponentMovement.Direction = DirectionLeft
+	sword.ComponentMovement.Direction = DirectionLeft
+	want := []drawn{{skull.ID(), "default"}, {player.ID(), "left"}}
+	if got := draws(); !equalDrawn(got, want) {
+		t.Errorf("idle: drew %v, want %v", got, want)
+	}
+
+	sword.ComponentIgnore.Value = false
+	player.ComponentAnimation.Key = "swordAttackLeft"
+	want = []drawn{{skull.ID(), "default"}, {sword.ID(), "left"}, {player.ID(), "swordAttackLeft"}}
+	if got := draws(); !equalDrawn(got, want) {
+		t.Errorf("attacking: drew %v, want %v", got, want)
+	}
+}
+
+func equalDrawn(a, b interface{}) bool {
+	return fmt.Sprint(a) == fmt.Sprint(b)
+}
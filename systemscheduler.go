@@ -0,0 +1,149 @@
+package zelduh
+
+import "time"
+
+// TimerID identifies a timer created by a SystemScheduler
+type TimerID uint64
+
+type timer struct {
+	id        TimerID
+	owner     EntityID
+	roomID    RoomID
+	inRoom    bool
+	remaining time.Duration
+	interval  time.Duration
+	repeat    bool
+	cancelled bool
+	fn        func()
+}
+
+// SystemScheduler runs timers and expires temporary entities, once per tick
+//
+// A timer is owned by an entity or by a room. Timers owned by an entity are
+// cancelled once the entity is destroyed, and timers owned by a room are
+// cancelled by CancelRoom, which is meant to be registered as a RoomLifecycle
+// unload hook. A timer fires at most once per tick.
+//
+// Timers count game time, so they only run while the scheduler is enabled:
+// they keep counting through room transitions, and stop while the game is
+// paused or on the start, loading or error screens.
+type SystemScheduler struct {
+	SystemsManager *SystemsManager
+
+	timers      []*timer
+	lastTimerID TimerID
+}
+
+// After calls fn once, delay from now, unless owner is destroyed first
+func (s *SystemScheduler) After(owner EntityID, delay time.Duration, fn func()) TimerID {
+	return s.add(&timer{owner: owner, remaining: delay, fn: fn})
+}
+
+// Every calls fn every interval, until it is cancelled or owner is destroyed
+func (s *SystemScheduler) Every(owner EntityID, interval time.Duration, fn func()) TimerID {
+	return s.add(&timer{owner: owner, remaining: interval, interval: interval, repeat: true, fn: fn})
+}
+
+// AfterInRoom calls fn once, delay from now, unless the room is unloaded first
+func (s *SystemScheduler) AfterInRoom(roomID RoomID, delay time.Duration, fn func()) TimerID {
+	return s.add(&timer{roomID: roomID, inRoom: true, remaining: delay, fn: fn})
+}
+
+// EveryInRoom calls fn every interval, until it is cancelled or the room is unloaded
+func (s *SystemScheduler) EveryInRoom(roomID RoomID, interval time.Duration, fn func()) TimerID {
+	return s.add(&timer{roomID: roomID, inRoom: true, remaining: interval, interval: interval, repeat: true, fn: fn})
+}
+
+func (s *SystemScheduler) add(t *timer) TimerID {
+	s.lastTimerID++
+	t.id = s.lastTimerID
+	s.timers = append(s.timers, t)
+	return t.id
+}
+
+// Active returns true if the timer has yet to fire, or is repeating, and was not cancelled
+func (s *SystemScheduler) Active(id TimerID) bool {
+	for _, t := range s.timers {
+		if t.id == id {
+			return !t.cancelled && (t.owner == 0 || s.SystemsManager.Valid(t.owner))
+		}
+	}
+	return false
+}
+
+// Cancel stops the timer; cancelling a timer that already finished does nothing
+func (s *SystemScheduler) Cancel(id TimerID) {
+	for _, t := range s.timers {
+		if t.id == id {
+			t.cancelled = true
+		}
+	}
+}
+
+// CancelRoom stops every timer owned by the room
+func (s *SystemScheduler) CancelRoom(roomID RoomID) {
+	for _, t := range s.timers {
+		if t.inRoom && t.roomID == roomID {
+			t.cancelled = true
+		}
+	}
+}
+
+// EntityQuery matches the temporary entities SystemScheduler expires
+func (s *SystemScheduler) EntityQuery() (EntityCategory, ComponentType) {
+	return 0, ComponentTypeTemporary
+}
+
+// Update expires temporary entities and advances timers by dt seconds
+//
+// Timers created by a timer callback start counting on the next tick.
+func (s *SystemScheduler) Update(dt float64) {
+	s.expireTemporary()
+
+	step := time.Duration(dt * float64(time.Second))
+	for _, t := range s.timers {
+		if t.cancelled {
+			continue
+		}
+		if t.owner != 0 && !s.SystemsManager.Valid(t.owner) {
+			t.cancelled = true
+			continue
+		}
+		t.remaining -= step
+		if t.remaining > 0 {
+			continue
+		}
+		if t.repeat {
+			t.remaining += t.interval
+		} else {
+			t.cancelled = true
+		}
+		t.fn()
+	}
+
+	active := s.timers[:0]
+	for _, t := range s.timers {
+		if !t.cancelled {
+			active = append(active, t)
+		}
+	}
+	for i := len(active); i < len(s.timers); i++ {
+		s.timers[i] = nil
+	}
+	s.timers = active
+}
+
+// expireTemporary counts down ComponentTemporary expirations, and destroys the
+// entities that reach zero
+func (s *SystemScheduler) expireTemporary() {
+	for _, entity := range s.SystemsManager.Query(0, ComponentTypeTemporary) {
+		if entity.ComponentTemporary.Expiration == 0 {
+			if entity.ComponentTemporary.OnExpiration != nil {
+				entity.ComponentTemporary.OnExpiration()
+			}
+			s.SystemsManager.Commands.Destroy(entity.ID())
+		} else if entity.ComponentTemporary.Expiration > 0 {
+			entity.ComponentTemporary.Expiration--
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package zelduh
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// schedulerStep is a tick length that converts to a whole number of nanoseconds
+const schedulerStep = 0.01
+
+func TestSystemSchedulerTimers(t *testing.T) {
+	w := NewSystemsManager(1)
+	s := &SystemScheduler{SystemsManager: &w}
+	owner := w.NewEntityID()
+
+	var once, every []int
+	tick := 0
+	onceID := s.After(owner, 30*time.Millisecond, func() { once = append(once, tick) })
+	everyID := s.Every(owner, 20*time.Millisecond, func() { every = append(every, tick) })
+
+	for tick = 1; tick <= 6; tick++ {
+		s.Update(schedulerStep)
+	}
+
+	if want := []int{3}; !reflect.DeepEqual(once, want) {
+		t.Errorf("After fired on ticks %v, want %v", once, want)
+	}
+	if want := []int{2, 4, 6}; !reflect.DeepEqual(every, want) {
+		t.Errorf("Every fired on ticks %v, want %v", every, want)
+	}
+	if s.Active(onceID) {
+		t.Error("one-shot timer still active after firing")
+	}
+	if !s.Active(everyID) {
+		t.Error("repeating timer not active")
+	}
+
+	s.Cancel(everyID)
+	s.Update(schedulerStep)
+	s.Update(schedulerStep)
+	if len(every) != 3 {
+		t.Errorf("cancelled timer fired again: %v", every)
+	}
+	if len(s.timers) != 0 {
+		t.Errorf("%d timers kept after finishing", len(s.timers))
+	}
+}
+
+func TestSystemSchedulerTimerCreatedInCallback(t *testing.T) {
+	w := NewSystemsManager(1)
+	s := &SystemScheduler{SystemsManager: &w}
+	owner := w.NewEntityID()
+
+	var fired []int
+	tick := 1
+	s.After(owner, 0, func() {
+		fired = append(fired, tick)
+		s.After(owner, 0, func() { fired = append(fired, tick) })
+	})
+
+	s.Update(schedulerStep)
+	if want := []int{1}; !reflect.DeepEqual(fired, want) {
+		t.Fatalf("after tick 1, fired on %v, want %v", fired, want)
+	}
+	tick = 2
+	s.Update(schedulerStep)
+	if want := []int{1, 2}; !reflect.DeepEqual(fired, want) {
+		t.Errorf("after tick 2, fired on %v, want %v", fired, want)
+	}
+}
+
+func TestSystemSchedulerCancelsWhenOwnerDestroyed(t *testing.T) {
+	w := NewSystemsManager(1)
+	s := &SystemScheduler{SystemsManager: &w}
+	owner := w.NewEntityID()
+
+	fired := 0
+	id := s.Every(owner, 10*time.Millisecond, func() { fired++ })
+	s.Update(schedulerStep)
+
+	w.DestroyEntity(owner)
+	// The slot's next occupant must not keep the timer alive
+	w.NewEntityID()
+	if s.Active(id) {
+		t.Error("timer active after its owner was destroyed")
+	}
+
+	s.Update(schedulerStep)
+	if fired != 1 {
+		t.Errorf("fired %d times, want 1", fired)
+	}
+	if len(s.timers) != 0 {
+		t.Errorf("%d timers kept after their owner was destroyed", len(s.timers))
+	}
+}
+
+func TestSystemSchedulerCancelRoomOnUnload(t *testing.T) {
+	RoomsMap = Rooms{
+		1: NewRoom("overworldOpen"),
+		2: NewRoom("overworldOpen"),
+	}
+	w := NewSystemsManager(1)
+	s := &SystemScheduler{SystemsManager: &w}
+	rooms := NewRoomLifecycle(&w, NewEntityByEntityIDMap(), map[EntityID]EntityConfig{}, NewMapStore("assets/tilemaps/", 48), DefaultConfig())
+	rooms.OnUnload(s.CancelRoom)
+
+	if err := rooms.Load(1); err != nil {
+		t.Fatal(err)
+	}
+	var fired []RoomID
+	left := s.AfterInRoom(1, 10*time.Millisecond, func() { fired = append(fired, 1) })
+	other := s.EveryInRoom(2, 10*time.Millisecond, func() { fired = append(fired, 2) })
+
+	if err := rooms.Load(2); err != nil {
+		t.Fatal(err)
+	}
+	if s.Active(left) {
+		t.Error("timer of the unloaded room is active")
+	}
+	if !s.Active(other) {
+		t.Error("timer of another room was cancelled")
+	}
+
+	s.Update(schedulerStep)
+	if want := []RoomID{2}; !reflect.DeepEqual(fired, want) {
+		t.Errorf("fired for rooms %v, want %v", fired, want)
+	}
+}
@@ -1,31 +1,171 @@
 package zelduh
 
+import (
+	"fmt"
+	"time"
+)
+
 // System is an interface
 type System interface {
-	Update()
-	AddEntity(Entity)
+	// Update advances the system by one simulation tick of dt seconds
+	Update(dt float64)
+}
+
+// SystemPhase groups systems by the part of the tick they run in
+//
+// Systems run in phase order, and in the order they were added within a phase.
+type SystemPhase int
+
+const (
+	SystemPhaseInput SystemPhase = iota
+	SystemPhaseSimulation
+	SystemPhaseCollision
+	SystemPhaseRender
+)
+
+// Names of the built in systems
+const (
+	SystemNameInput     = "input"
+	SystemNameScheduler = "scheduler"
+	SystemNameHealth    = "health"
+	SystemNameSpatial   = "spatial"
+	SystemNameCollision = "collision"
+	SystemNameRender    = "render"
+)
+
+// systemEntry is a system registered with a SystemsManager
+type systemEntry struct {
+	name    string
+	phase   SystemPhase
+	system  System
+	enabled bool
+}
+
+// SystemQuerier is implemented by systems that can report which entities they
+// process, so the Profiler can count them
+type SystemQuerier interface {
+	// EntityQuery returns the Query arguments matching the entities the system processes
+	EntityQuery() (EntityCategory, ComponentType)
+}
+
+// SystemDrawer is implemented by systems that draw once per rendered frame
+type SystemDrawer interface {
+	// Draw draws the system, alpha of the way from the previous tick to the current one
+	Draw(alpha float64)
+}
+
+// entitySlot holds the entity stored under one ID index
+type entitySlot struct {
+	generation uint32
+	// live is true from NewEntityID until the entity is destroyed
+	live bool
+	// stored is true while the entity has been added to the world
+	stored bool
+	entity Entity
 }
 
 // SystemsManager is a world struct
+//
+// It owns every entity in the game. Systems read entities back out of it with
+// Query instead of keeping their own copies.
 type SystemsManager struct {
-	systems           []System
-	SystemsMap        map[string]System
-	lastEntityID      EntityID
+	systems           []systemEntry
 	shouldAddEntities bool
+	slots             []entitySlot
+	freeSlots         []uint32
+	entityOrder       []EntityID
+	names             map[string]EntityID
+	tags              map[string][]EntityID
+	Events            EventBus
+	Commands          CommandBuffer
+	Timestep          FixedTimestep
+	Random            Random
+	Profiler          Profiler
 }
 
-// NewSystemsManager returns a new SystemsManager
-func NewSystemsManager() SystemsManager {
+// NewSystemsManager returns a new SystemsManager, with its Random seeded with seed
+func NewSystemsManager(seed int64) SystemsManager {
 	return SystemsManager{
-		lastEntityID:      0,
-		SystemsMap:        map[string]System{},
 		shouldAddEntities: true,
+		Events:            NewEventBus(),
+		Timestep:          NewFixedTimestep(TicksPerSecond),
+		Random:            NewRandom(seed),
 	}
 }
 
-// AddSystem adds a System to the SystemsManager
-func (w *SystemsManager) AddSystem(sys System) {
-	w.systems = append(w.systems, sys)
+// AddSystem registers an enabled System under a unique name, to run in the given phase
+func (w *SystemsManager) AddSystem(name string, phase SystemPhase, sys System) {
+	if _, ok := w.System(name); ok {
+		panic(fmt.Sprintf("system %q is already registered", name))
+	}
+
+	// Insert after the last system of the same or an earlier phase
+	i := len(w.systems)
+	for i > 0 && w.systems[i-1].phase > phase {
+		i--
+	}
+	w.systems = append(w.systems, systemEntry{})
+	copy(w.systems[i+1:], w.systems[i:])
+	w.systems[i] = systemEntry{
+		name:    name,
+		phase:   phase,
+		system:  sys,
+		enabled: true,
+	}
+}
+
+// System returns the system registered under the name
+func (w *SystemsManager) System(name string) (System, bool) {
+	for _, entry := range w.systems {
+		if entry.name == name {
+			return entry.system, true
+		}
+	}
+	return nil, false
+}
+
+// SetSystemEnabled enables or disables the named system
+//
+// Disabled systems are skipped by Update and Draw.
+func (w *SystemsManager) SetSystemEnabled(name string, enabled bool) {
+	for i := range w.systems {
+		if w.systems[i].name == name {
+			w.systems[i].enabled = enabled
+			return
+		}
+	}
+	panic(fmt.Sprintf("system %q is not registered", name))
+}
+
+// SystemEnabled returns true if the named system is registered and enabled
+func (w *SystemsManager) SystemEnabled(name string) bool {
+	for _, entry := range w.systems {
+		if entry.name == name {
+			return entry.enabled
+		}
+	}
+	return false
+}
+
+// EnableOnlySystems enables the named systems and disables all others
+//
+// Names that are not registered are ignored, so a game may leave out systems,
+// such as rendering in a headless test, that its states would run.
+func (w *SystemsManager) EnableOnlySystems(names ...string) {
+	enabled := map[string]bool{}
+	for _, name := range names {
+		enabled[name] = true
+	}
+	for i := range w.systems {
+		w.systems[i].enabled = enabled[w.systems[i].name]
+	}
+}
+
+// EnableAllSystems enables every system
+func (w *SystemsManager) EnableAllSystems() {
+	for i := range w.systems {
+		w.systems[i].enabled = true
+	}
 }
 
 func (w *SystemsManager) SetShouldAddEntities(value bool) {
@@ -36,123 +176,308 @@ func (w *SystemsManager) GetShouldAddEntities() bool {
 	return w.shouldAddEntities
 }
 
-// AddSystems adds a batch of systems to the world
-func (w *SystemsManager) AddSystems(all ...System) {
-	for _, sys := range all {
-		w.AddSystem(sys)
+// Update advances the simulation by dt seconds of frame time, running as many
+// fixed ticks as fit, and returns the number of ticks run
+func (w *SystemsManager) Update(dt float64) int {
+	ticks := w.Timestep.Advance(dt)
+	for i := 0; i < ticks; i++ {
+		w.tick(w.Timestep.Step)
 	}
+	return ticks
 }
 
-// Update executes Update on all systems in this SystemsManager
-func (w *SystemsManager) Update() {
-	for _, sys := range w.systems {
-		sys.Update()
+// tick executes Update on all enabled systems in this SystemsManager, in phase order
+//
+// Events published by a system are dispatched as soon as that system's Update
+// returns, before the next system runs. Entity spawns and destroys queued in
+// Commands by the system or by event handlers are applied after that.
+func (w *SystemsManager) tick(dt float64) {
+	w.flush()
+	for _, entity := range w.Query(0, ComponentTypeSpatial) {
+		entity.ComponentSpatial.PrevRect = entity.ComponentSpatial.Rect
+	}
+	for _, entry := range w.systems {
+		if !entry.enabled {
+			continue
+		}
+		if !w.Profiler.Enabled {
+			entry.system.Update(dt)
+			w.flush()
+			continue
+		}
+		start := time.Now()
+		entry.system.Update(dt)
+		w.flush()
+		w.Profiler.recordUpdate(entry.name, time.Since(start), w.entityCount(entry.system))
 	}
 }
 
-// Systems returns the systems in this SystemsManager
+// entityCount returns the number of entities the system processes, or -1 if
+// it does not say
+func (w *SystemsManager) entityCount(system System) int {
+	querier, ok := system.(SystemQuerier)
+	if !ok {
+		return -1
+	}
+	return w.Count(querier.EntityQuery())
+}
+
+// flush dispatches queued events, then applies queued entity commands
+func (w *SystemsManager) flush() {
+	w.Events.Dispatch()
+	w.Commands.apply(w)
+}
+
+// Draw executes Draw on all enabled systems that implement SystemDrawer
+func (w *SystemsManager) Draw() {
+	alpha := w.Timestep.Alpha()
+	for _, entry := range w.systems {
+		if !entry.enabled {
+			continue
+		}
+		drawer, ok := entry.system.(SystemDrawer)
+		if !ok {
+			continue
+		}
+		if !w.Profiler.Enabled {
+			drawer.Draw(alpha)
+			continue
+		}
+		start := time.Now()
+		drawer.Draw(alpha)
+		w.Profiler.recordDraw(entry.name, time.Since(start))
+	}
+}
+
+// Systems returns the systems in this SystemsManager, in the order they run
 func (w *SystemsManager) Systems() []System {
-	return w.systems
+	systems := make([]System, len(w.systems))
+	for i, entry := range w.systems {
+		systems[i] = entry.system
+	}
+	return systems
 }
 
 // NewEntityID generates and returns a new Entity ID
+//
+// Slots freed by DestroyEntity are reused, under a new generation.
 func (w *SystemsManager) NewEntityID() EntityID {
-	w.lastEntityID++
-	return w.lastEntityID
+	if n := len(w.freeSlots); n > 0 {
+		index := w.freeSlots[n-1]
+		w.freeSlots = w.freeSlots[:n-1]
+		slot := &w.slots[index]
+		slot.live = true
+		return newEntityID(index, slot.generation)
+	}
+	index := uint32(len(w.slots))
+	w.slots = append(w.slots, entitySlot{
+		generation: 1,
+		live:       true,
+	})
+	return newEntityID(index, 1)
 }
 
-// AddEntity adds the entity to it's system
-func (w *SystemsManager) AddEntity(entity Entity) {
-	for _, system := range w.Systems() {
-		system.AddEntity(entity)
+// slot returns the slot the ID refers to, or nil if the ID is stale or was
+// never handed out
+func (w *SystemsManager) slot(id EntityID) *entitySlot {
+	index := id.Index()
+	if int(index) >= len(w.slots) {
+		return nil
 	}
+	slot := &w.slots[index]
+	if !slot.live || slot.generation != id.Generation() {
+		return nil
+	}
+	return slot
 }
 
-// AddEntities adds the entities to their system
-func (w *SystemsManager) AddEntities(all ...Entity) {
-	for _, entity := range all {
-		w.AddEntity(entity)
+// Valid returns true if the ID was handed out by NewEntityID and its entity
+// has not been destroyed since
+func (w *SystemsManager) Valid(id EntityID) bool {
+	return w.slot(id) != nil
+}
+
+// AddEntity stores the entity, replacing any entity with the same ID
+//
+// Entities whose ID is stale, or whose name is taken by another entity, are
+// ignored, and false is returned.
+// Systems and event handlers should use Commands.Spawn instead.
+func (w *SystemsManager) AddEntity(entity Entity) bool {
+	id := entity.ID()
+	slot := w.slot(id)
+	if slot == nil {
+		return false
+	}
+	if owner, ok := w.names[entity.Name]; ok && entity.Name != "" && owner != id {
+		return false
+	}
+	if slot.stored {
+		w.unindex(slot.entity)
+	} else {
+		slot.stored = true
+		w.entityOrder = append(w.entityOrder, id)
 	}
+	slot.entity = entity
+	w.index(entity)
+	return true
 }
 
-// Remove removes the specific entity from all systems
-func (w *SystemsManager) Remove(category EntityCategory, id EntityID) {
-	switch category {
-	case CategoryCoin:
-		for _, sys := range w.systems {
-			switch sys := sys.(type) {
-			case *SystemCollision:
-				sys.Remove(CategoryCoin, id)
-			case *SystemRender:
-				sys.RemoveEntity(id)
+// index records the entity's name and tags, for FindByName and FindByTag
+func (w *SystemsManager) index(entity Entity) {
+	if w.names == nil {
+		w.names = map[string]EntityID{}
+		w.tags = map[string][]EntityID{}
+	}
+	if entity.Name != "" {
+		w.names[entity.Name] = entity.ID()
+	}
+	for _, tag := range entity.Tags {
+		w.tags[tag] = append(w.tags[tag], entity.ID())
+	}
+}
+
+// unindex forgets the entity's name and tags
+func (w *SystemsManager) unindex(entity Entity) {
+	if entity.Name != "" {
+		delete(w.names, entity.Name)
+	}
+	for _, tag := range entity.Tags {
+		ids := w.tags[tag]
+		for i, id := range ids {
+			if id == entity.ID() {
+				ids = append(ids[:i], ids[i+1:]...)
+				break
 			}
 		}
-	case CategoryHeart:
-		for _, sys := range w.systems {
-			switch sys := sys.(type) {
-			case *SystemRender:
-				sys.RemoveEntity(id)
-			}
+		if len(ids) == 0 {
+			delete(w.tags, tag)
+		} else {
+			w.tags[tag] = ids
+		}
+	}
+}
+
+// FindByName returns the entity with the given name
+func (w *SystemsManager) FindByName(name string) (Entity, bool) {
+	id, ok := w.names[name]
+	if !ok {
+		return Entity{}, false
+	}
+	return w.Entity(id)
+}
+
+// FindByTag returns the entities tagged with tag, in the order they were tagged
+func (w *SystemsManager) FindByTag(tag string) []Entity {
+	result := []Entity{}
+	for _, id := range w.tags[tag] {
+		if entity, ok := w.Entity(id); ok {
+			result = append(result, entity)
 		}
 	}
+	return result
+}
+
+// AddEntities stores a batch of entities
+func (w *SystemsManager) AddEntities(all ...Entity) {
+	for _, entity := range all {
+		w.AddEntity(entity)
+	}
+}
+
+// Entity returns the entity with the given ID
+//
+// It returns false if the entity was not added, or the ID is stale.
+func (w *SystemsManager) Entity(id EntityID) (Entity, bool) {
+	slot := w.slot(id)
+	if slot == nil || !slot.stored {
+		return Entity{}, false
+	}
+	return slot.entity, true
 }
 
-// RemoveEnemy removes the enemy from all system
-func (w *SystemsManager) RemoveEnemy(id EntityID) {
-	for _, sys := range w.systems {
-		switch sys := sys.(type) {
-		case *SystemSpatial:
-			sys.Remove(CategoryEnemy, id)
-		case *SystemCollision:
-			sys.Remove(CategoryEnemy, id)
-		case *SystemRender:
-			sys.RemoveEntity(id)
+// Query returns the entities that belong to one of the categories and have
+// all of the components, in the order they were added
+//
+// A categories value of 0 matches entities of any category.
+func (w *SystemsManager) Query(categories EntityCategory, components ComponentType) []Entity {
+	result := []Entity{}
+	for _, id := range w.entityOrder {
+		entity := w.slots[id.Index()].entity
+		if matches(entity, categories, components) {
+			result = append(result, entity)
 		}
 	}
+	return result
 }
 
-// RemoveAllEnemies removes all enemies from all systems
-func (w *SystemsManager) RemoveAllEnemies() {
-	for _, system := range w.Systems() {
-		switch sys := system.(type) {
-		case *SystemSpatial:
-			sys.RemoveAll(CategoryEnemy)
-		case *SystemCollision:
-			sys.RemoveAll(CategoryEnemy)
-		case *SystemRender:
-			sys.RemoveAll(CategoryEnemy)
+// Count returns the number of entities Query would return, without building the list
+func (w *SystemsManager) Count(categories EntityCategory, components ComponentType) int {
+	count := 0
+	for _, id := range w.entityOrder {
+		if matches(w.slots[id.Index()].entity, categories, components) {
+			count++
 		}
 	}
+	return count
 }
 
-// RemoveAllEntities removes all entities from systems
-func (w *SystemsManager) RemoveAllEntities() {
-	for _, system := range w.Systems() {
-		switch sys := system.(type) {
-		case *SystemRender:
-			sys.RemoveAllEntities()
+func matches(entity Entity, categories EntityCategory, components ComponentType) bool {
+	if categories != 0 && entity.Category&categories == 0 {
+		return false
+	}
+	return entity.Has(components)
+}
+
+// First returns the first entity that Query would return
+func (w *SystemsManager) First(categories EntityCategory, components ComponentType) (Entity, bool) {
+	for _, id := range w.entityOrder {
+		entity := w.slots[id.Index()].entity
+		if categories != 0 && entity.Category&categories == 0 {
+			continue
+		}
+		if entity.Has(components) {
+			return entity, true
 		}
 	}
+	return Entity{}, false
 }
 
-// RemoveAllMoveableObstacles removes all moveable obstacles from systems
-func (w *SystemsManager) RemoveAllMoveableObstacles() {
-	for _, system := range w.Systems() {
-		switch sys := system.(type) {
-		case *SystemCollision:
-			sys.RemoveAll(CategoryMovableObstacle)
-		case *SystemRender:
-			sys.RemoveAll(CategoryMovableObstacle)
+// DestroyEntity removes the entity from the world, and so from every system,
+// and frees its ID for reuse
+//
+// Destroying an entity with a stale ID does nothing.
+// Systems and event handlers should use Commands.Destroy instead.
+func (w *SystemsManager) DestroyEntity(id EntityID) {
+	slot := w.slot(id)
+	if slot == nil {
+		return
+	}
+	if slot.stored {
+		w.unindex(slot.entity)
+		for i, entityID := range w.entityOrder {
+			if entityID == id {
+				w.entityOrder = append(w.entityOrder[:i], w.entityOrder[i+1:]...)
+				break
+			}
 		}
 	}
+	slot.live = false
+	slot.stored = false
+	slot.entity = Entity{}
+	slot.generation++
+	if slot.generation == 0 {
+		// Generation 0 is never handed out, so that no ID is 0
+		slot.generation = 1
+	}
+	w.freeSlots = append(w.freeSlots, id.Index())
 }
 
-// RemoveAllCollisionSwitches removes all collision switches from systems
-func (w *SystemsManager) RemoveAllCollisionSwitches() {
-	for _, system := range w.Systems() {
-		switch sys := system.(type) {
-		case *SystemCollision:
-			sys.RemoveAll(CategoryCollisionSwitch)
+// DestroyAllExcept removes every entity that does not belong to one of the categories
+func (w *SystemsManager) DestroyAllExcept(categories EntityCategory) {
+	for i := len(w.entityOrder) - 1; i >= 0; i-- {
+		id := w.entityOrder[i]
+		if w.slots[id.Index()].entity.Category&categories == 0 {
+			w.DestroyEntity(id)
 		}
 	}
 }
@@ -0,0 +1,224 @@
+package zelduh
+
+import "testing"
+
+// addTestEntity adds an entity of the category, with the components set in
+// components, and returns it
+func addTestEntity(t *testing.T, w *SystemsManager, category EntityCategory, components ComponentType) Entity {
+	t.Helper()
+	entity := Entity{
+		id:       w.NewEntityID(),
+		Category: category,
+	}
+	if components&ComponentTypeSpatial != 0 {
+		entity.ComponentSpatial = &ComponentSpatial{}
+	}
+	if components&ComponentTypeMovement != 0 {
+		entity.ComponentMovement = &ComponentMovement{}
+	}
+	if components&ComponentTypeHealth != 0 {
+		entity.ComponentHealth = &ComponentHealth{}
+	}
+	if !w.AddEntity(entity) {
+		t.Fatalf("AddEntity(%v) = false", entity.ID())
+	}
+	return entity
+}
+
+func entityIDs(entities []Entity) []EntityID {
+	ids := []EntityID{}
+	for _, entity := range entities {
+		ids = append(ids, entity.ID())
+	}
+	return ids
+}
+
+func equalIDs(a, b []EntityID) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSystemsManagerQuery(t *testing.T) {
+	w := NewSystemsManager(1)
+	player := addTestEntity(t, &w, CategoryPlayer, ComponentTypeSpatial|ComponentTypeMovement|ComponentTypeHealth)
+	enemy := addTestEntity(t, &w, CategoryEnemy, ComponentTypeSpatial|ComponentTypeMovement)
+	obstacle := addTestEntity(t, &w, CategoryObstacle, ComponentTypeSpatial)
+	coin := addTestEntity(t, &w, CategoryCoin, 0)
+
+	tests := []struct {
+		name       string
+		categories EntityCategory
+		components ComponentType
+		want       []EntityID
+	}{
+		{"everything", 0, 0, []EntityID{player.ID(), enemy.ID(), obstacle.ID(), coin.ID()}},
+		{"one category", CategoryEnemy, 0, []EntityID{enemy.ID()}},
+		{"any of several categories", CategoryPlayer | CategoryObstacle, 0, []EntityID{player.ID(), obstacle.ID()}},
+		{"one component", 0, ComponentTypeSpatial, []EntityID{player.ID(), enemy.ID(), obstacle.ID()}},
+		{"all of several components", 0, ComponentTypeSpatial | ComponentTypeMovement, []EntityID{player.ID(), enemy.ID()}},
+		{"category and components", CategoryEnemy | CategoryObstacle, ComponentTypeMovement, []EntityID{enemy.ID()}},
+		{"no match", CategoryCoin, ComponentTypeSpatial, []EntityID{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := entityIDs(w.Query(tt.categories, tt.components)); !equalIDs(got, tt.want) {
+				t.Errorf("Query = %v, want %v", got, tt.want)
+			}
+			if got := w.Count(tt.categories, tt.components); got != len(tt.want) {
+				t.Errorf("Count = %d, want %d", got, len(tt.want))
+			}
+			first, ok := w.First(tt.categories, tt.components)
+			if ok != (len(tt.want) > 0) {
+				t.Fatalf("First ok = %v, want %v", ok, len(tt.want) > 0)
+			}
+			if ok && first.ID() != tt.want[0] {
+				t.Errorf("First = %v, want %v", first.ID(), tt.want[0])
+			}
+		})
+	}
+}
+
+func TestSystemsManagerDestroyEntityKeepsOrder(t *testing.T) {
+	w := NewSystemsManager(1)
+	a := addTestEntity(t, &w, CategoryEnemy, 0)
+	b := addTestEntity(t, &w, CategoryEnemy, 0)
+	c := addTestEntity(t, &w, CategoryEnemy, 0)
+	d := addTestEntity(t, &w, CategoryEnemy, 0)
+
+	w.DestroyEntity(b.ID())
+	if got, want := entityIDs(w.Query(0, 0)), []EntityID{a.ID(), c.ID(), d.ID()}; !equalIDs(got, want) {
+		t.Errorf("after destroying b, Query = %v, want %v", got, want)
+	}
+
+	// A new entity reuses b's slot, but is queried after the entities added before it
+	e := addTestEntity(t, &w, CategoryEnemy, 0)
+	if got, want := entityIDs(w.Query(0, 0)), []EntityID{a.ID(), c.ID(), d.ID(), e.ID()}; !equalIDs(got, want) {
+		t.Errorf("after adding e, Query = %v, want %v", got, want)
+	}
+}
+
+func TestSystemsManagerDestroyAllExcept(t *testing.T) {
+	w := NewSystemsManager(1)
+	player := addTestEntity(t, &w, CategoryPlayer, 0)
+	addTestEntity(t, &w, CategoryEnemy, 0)
+	sword := addTestEntity(t, &w, CategorySword, 0)
+	addTestEntity(t, &w, CategoryObstacle, 0)
+	addTestEntity(t, &w, CategoryEnemy, 0)
+
+	w.DestroyAllExcept(CategoryPlayer | CategorySword)
+
+	if got, want := entityIDs(w.Query(0, 0)), []EntityID{player.ID(), sword.ID()}; !equalIDs(got, want) {
+		t.Errorf("Query = %v, want %v", got, want)
+	}
+	if got := w.Count(CategoryEnemy, 0); got != 0 {
+		t.Errorf("enemies left = %d, want 0", got)
+	}
+}
+
+func TestSystemsManagerReusesSlotUnderNewGeneration(t *testing.T) {
+	w := NewSystemsManager(1)
+	stale := addTestEntity(t, &w, CategoryEnemy, 0)
+	w.DestroyEntity(stale.ID())
+
+	id := w.NewEntityID()
+	if id.Index() != stale.ID().Index() {
+		t.Errorf("new ID index = %d, want reused index %d", id.Index(), stale.ID().Index())
+	}
+	if id.Generation() != stale.ID().Generation()+1 {
+		t.Errorf("new ID generation = %d, want %d", id.Generation(), stale.ID().Generation()+1)
+	}
+	if id == stale.ID() {
+		t.Fatal("new ID equals the stale ID")
+	}
+
+	if w.Valid(stale.ID()) {
+		t.Error("Valid(stale) = true")
+	}
+	if !w.Valid(id) {
+		t.Error("Valid(new) = false")
+	}
+	if _, ok := w.Entity(stale.ID()); ok {
+		t.Error("Entity(stale) found an entity")
+	}
+	if w.AddEntity(stale) {
+		t.Error("AddEntity with a stale ID = true")
+	}
+	if w.Count(0, 0) != 0 {
+		t.Errorf("Count = %d after adding a stale entity, want 0", w.Count(0, 0))
+	}
+
+	// Destroying the stale ID must not destroy the slot's new occupant
+	reused := Entity{id: id, Category: CategoryEnemy}
+	if !w.AddEntity(reused) {
+		t.Fatal("AddEntity with the new ID = false")
+	}
+	w.DestroyEntity(stale.ID())
+	if _, ok := w.Entity(id); !ok {
+		t.Error("destroying the stale ID destroyed the new entity")
+	}
+}
+
+func TestSystemsManagerIDNeverZero(t *testing.T) {
+	w := NewSystemsManager(1)
+	id := w.NewEntityID()
+	w.slots[id.Index()].generation = ^uint32(0)
+	id = newEntityID(id.Index(), ^uint32(0))
+	w.DestroyEntity(id)
+
+	if id := w.NewEntityID(); id == 0 || id.Generation() != 1 {
+		t.Errorf("ID after generation wraps = %x, want generation 1", uint64(id))
+	}
+}
+
+func TestSystemsManagerFindAfterDestroy(t *testing.T) {
+	w := NewSystemsManager(1)
+	build := func() Entity {
+		entity := Entity{id: w.NewEntityID(), Category: CategoryEnemy, Name: "boss", Tags: []string{"enemy", "boss"}}
+		if !w.AddEntity(entity) {
+			t.Fatalf("AddEntity(%v) = false", entity.ID())
+		}
+		return entity
+	}
+	minion := Entity{id: w.NewEntityID(), Category: CategoryEnemy, Tags: []string{"enemy"}}
+	w.AddEntity(minion)
+
+	first := build()
+	if found, ok := w.FindByName("boss"); !ok || found.ID() != first.ID() {
+		t.Fatalf("FindByName = %v, %v, want %v", found.ID(), ok, first.ID())
+	}
+	taken := Entity{id: w.NewEntityID(), Name: "boss"}
+	if w.AddEntity(taken) {
+		t.Error("AddEntity with a taken name = true")
+	}
+	w.DestroyEntity(taken.ID())
+
+	w.DestroyEntity(first.ID())
+	if _, ok := w.FindByName("boss"); ok {
+		t.Error("FindByName found a destroyed entity")
+	}
+	if got := w.FindByTag("boss"); len(got) != 0 {
+		t.Errorf("FindByTag(boss) = %v, want none", entityIDs(got))
+	}
+	if got, want := entityIDs(w.FindByTag("enemy")), []EntityID{minion.ID()}; !equalIDs(got, want) {
+		t.Errorf("FindByTag(enemy) = %v, want %v", got, want)
+	}
+
+	// The name and tags are free for a new entity, which may reuse the slot
+	second := build()
+	if found, ok := w.FindByName("boss"); !ok || found.ID() != second.ID() {
+		t.Errorf("FindByName = %v, %v, want %v", found.ID(), ok, second.ID())
+	}
+	if got, want := entityIDs(w.FindByTag("boss")), []EntityID{second.ID()}; !equalIDs(got, want) {
+		t.Errorf("FindByTag(boss) = %v, want %v", got, want)
+	}
+	if got, want := entityIDs(w.FindByTag("enemy")), []EntityID{minion.ID(), second.ID()}; !equalIDs(got, want) {
+		t.Errorf("FindByTag(enemy) = %v, want %v", got, want)
+	}
+}
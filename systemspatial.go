@@ -1,88 +1,42 @@
 package zelduh
 
 import (
-	"math/rand"
-
 	"github.com/faiface/pixel"
 )
 
-type spatialEntity struct {
-	ID EntityID
-	*ComponentMovement
-	*ComponentSpatial
-	*ComponentDash
-	TotalMoves  int
-	MoveCounter int
-}
-
 // SystemSpatial is a custom system
 type SystemSpatial struct {
-	Rand              *rand.Rand
-	player            spatialEntity
-	sword             spatialEntity
-	arrow             spatialEntity
-	enemies           []*spatialEntity
-	moveableObstacles []*spatialEntity
+	SystemsManager *SystemsManager
+	TileSize       float64
 }
 
-// AddEntity adds an entity to the system
-func (s *SystemSpatial) AddEntity(entity Entity) {
-	r := spatialEntity{
-		ID:                entity.ID(),
-		ComponentSpatial:  entity.ComponentSpatial,
-		ComponentMovement: entity.ComponentMovement,
-	}
-	switch entity.Category {
-	case CategoryPlayer:
-		r.ComponentDash = entity.ComponentDash
-		s.player = r
-	case CategorySword:
-		s.sword = r
-	case CategoryArrow:
-		s.arrow = r
-	case CategoryMovableObstacle:
-		s.moveableObstacles = append(s.moveableObstacles, &r)
-	case CategoryEnemy:
-		s.enemies = append(s.enemies, &r)
-	}
-}
+const spatialComponents = ComponentTypeSpatial | ComponentTypeMovement
 
-// Remove removes the entity from the system
-func (s *SystemSpatial) Remove(category EntityCategory, id EntityID) {
-	switch category {
-	case CategoryEnemy:
-		for i := len(s.enemies) - 1; i >= 0; i-- {
-			enemy := s.enemies[i]
-			if enemy.ID == id {
-				s.enemies = append(s.enemies[:i], s.enemies[i+1:]...)
-			}
-		}
-	}
+// EntityQuery matches the entities SystemSpatial moves
+func (s *SystemSpatial) EntityQuery() (EntityCategory, ComponentType) {
+	return 0, ComponentTypeSpatial | ComponentTypeMovement
 }
 
-// RemoveAll removes all entities from one category
-func (s *SystemSpatial) RemoveAll(category EntityCategory) {
-	switch category {
-	case CategoryEnemy:
-		for i := len(s.enemies) - 1; i >= 0; i-- {
-			s.enemies = append(s.enemies[:i], s.enemies[i+1:]...)
-		}
-	}
+func (s *SystemSpatial) player() (Entity, bool) {
+	return s.SystemsManager.First(CategoryPlayer, spatialComponents|ComponentTypeDash)
 }
 
-// MovePlayerBack moves the player back
+// MovePlayerBack moves the player back one tile
 func (s *SystemSpatial) MovePlayerBack() {
-	player := s.player
+	player, ok := s.player()
+	if !ok {
+		return
+	}
 	var v pixel.Vec
 	switch player.ComponentMovement.Direction {
 	case DirectionUp:
-		v = pixel.V(0, -48)
+		v = pixel.V(0, -s.TileSize)
 	case DirectionRight:
-		v = pixel.V(-48, 0)
+		v = pixel.V(-s.TileSize, 0)
 	case DirectionDown:
-		v = pixel.V(0, 48)
+		v = pixel.V(0, s.TileSize)
 	case DirectionLeft:
-		v = pixel.V(48, 0)
+		v = pixel.V(s.TileSize, 0)
 	}
 	player.ComponentSpatial.Rect = player.ComponentSpatial.PrevRect.Moved(v)
 	player.ComponentSpatial.PrevRect = player.ComponentSpatial.Rect
@@ -120,10 +74,9 @@ func (s *SystemSpatial) MoveEnemyBack(enemyID EntityID, directionHit Direction)
 
 // GetEnemySpatial returns the spatial component
 func (s *SystemSpatial) GetEnemySpatial(enemyID EntityID) (*ComponentSpatial, bool) {
-	for _, enemy := range s.enemies {
-		if enemy.ID == enemyID {
-			return enemy.ComponentSpatial, true
-		}
+	enemy, ok := s.enemy(enemyID)
+	if ok {
+		return enemy.ComponentSpatial, true
 	}
 	return &ComponentSpatial{}, false
 }
@@ -132,51 +85,83 @@ func (s *SystemSpatial) GetEnemySpatial(enemyID EntityID) (*ComponentSpatial, bo
 func (s *SystemSpatial) EnemyMovingFromHit(enemyID EntityID) bool {
 	enemy, ok := s.enemy(enemyID)
 	if ok {
-		if enemy.ID == enemyID {
-			return enemy.ComponentMovement.MovingFromHit == true
-		}
+		return enemy.ComponentMovement.MovingFromHit == true
 	}
 	return false
 }
 
 // Update changes spatial data based on movement data
-func (s *SystemSpatial) Update() {
-	s.movePlayer()
-	s.moveSword()
-	s.moveArrow()
+//
+// Speeds are in pixels per second and are scaled by dt, the length of the tick in seconds
+func (s *SystemSpatial) Update(dt float64) {
+	player, ok := s.player()
+	if !ok {
+		return
+	}
+	s.movePlayer(player, dt)
+	if arrow, ok := s.SystemsManager.First(CategoryArrow, spatialComponents); ok {
+		s.moveArrow(arrow, dt)
+	}
 
-	for i := 0; i < len(s.moveableObstacles); i++ {
-		entity := s.moveableObstacles[i]
-		s.moveMoveableObstacle(entity)
+	for _, entity := range s.SystemsManager.Query(CategoryMovableObstacle, spatialComponents) {
+		s.moveMoveableObstacle(entity, dt)
 	}
 
-	for i := 0; i < len(s.enemies); i++ {
-		enemy := s.enemies[i]
+	for _, enemy := range s.SystemsManager.Query(CategoryEnemy, spatialComponents) {
 		switch enemy.ComponentMovement.PatternName {
 		case "random":
-			s.moveEnemyRandom(enemy)
+			s.moveEnemyRandom(enemy, dt)
 		case "left-right":
-			s.moveEnemyLeftRight(enemy)
+			s.moveEnemyLeftRight(enemy, dt)
 		}
 	}
+
+	// Attached entities move last, so they follow where their parent ended up
+	for _, entity := range s.SystemsManager.Query(0, ComponentTypeSpatial|ComponentTypeAttachment) {
+		s.followParent(entity)
+	}
 }
 
-func (s *SystemSpatial) moveableObstacle(id EntityID) (spatialEntity, bool) {
-	for _, e := range s.moveableObstacles {
-		if e.ID == id {
-			return *e, true
+// followParent moves an attached entity to its parent, detaching it if the parent is gone
+func (s *SystemSpatial) followParent(entity Entity) {
+	parentID, ok := entity.ComponentAttachment.Parent()
+	if !ok {
+		return
+	}
+	parent, ok := s.SystemsManager.Entity(parentID)
+	if !ok || parent.ComponentSpatial == nil {
+		entity.ComponentAttachment.Detach()
+		return
+	}
+	var dir Direction
+	if parent.ComponentMovement != nil {
+		dir = parent.ComponentMovement.Direction
+		if entity.ComponentMovement != nil {
+			entity.ComponentMovement.Direction = dir
 		}
 	}
-	return spatialEntity{}, false
+	offset := entity.ComponentAttachment.Offsets[dir]
+	if entity.ComponentIgnore != nil && entity.ComponentIgnore.Value {
+		offset = pixel.ZV
+	}
+	entity.ComponentSpatial.PrevRect = entity.ComponentSpatial.Rect
+	entity.ComponentSpatial.Rect = parent.ComponentSpatial.Rect.Moved(offset)
 }
 
-func (s *SystemSpatial) enemy(id EntityID) (spatialEntity, bool) {
-	for _, e := range s.enemies {
-		if e.ID == id {
-			return *e, true
-		}
+func (s *SystemSpatial) moveableObstacle(id EntityID) (Entity, bool) {
+	return s.entity(id, CategoryMovableObstacle)
+}
+
+func (s *SystemSpatial) enemy(id EntityID) (Entity, bool) {
+	return s.entity(id, CategoryEnemy)
+}
+
+func (s *SystemSpatial) entity(id EntityID, category EntityCategory) (Entity, bool) {
+	entity, ok := s.SystemsManager.Entity(id)
+	if !ok || entity.Category != category || !entity.Has(spatialComponents) {
+		return Entity{}, false
 	}
-	return spatialEntity{}, false
+	return entity, true
 }
 
 func delta(dir Direction, modX, modY float64) pixel.Vec {
@@ -194,50 +179,33 @@ func delta(dir Direction, modX, modY float64) pixel.Vec {
 	}
 }
 
-func (s *SystemSpatial) moveSword() {
-	sword := s.sword
-	speed := sword.ComponentMovement.Speed
-	w := sword.ComponentSpatial.Width
-	h := sword.ComponentSpatial.Height
-	if speed > 0 {
-		sword.ComponentSpatial.PrevRect = sword.ComponentSpatial.Rect
-		v := delta(sword.ComponentMovement.Direction, speed+w, speed+h)
-		sword.ComponentSpatial.Rect = s.player.ComponentSpatial.Rect.Moved(v)
-	} else {
-		sword.ComponentSpatial.Rect = s.player.ComponentSpatial.Rect
-	}
-}
-
-func (s *SystemSpatial) moveArrow() {
-	arrow := s.arrow
+// moveArrow moves a fired arrow; until it is fired, it is attached to the player
+func (s *SystemSpatial) moveArrow(arrow Entity, dt float64) {
 	speed := arrow.ComponentMovement.Speed
 	if arrow.ComponentMovement.RemainingMoves > 0 {
 		arrow.ComponentSpatial.PrevRect = arrow.ComponentSpatial.Rect
-		v := delta(arrow.ComponentMovement.Direction, speed, speed)
+		v := delta(arrow.ComponentMovement.Direction, speed*dt, speed*dt)
 		arrow.ComponentSpatial.Rect = arrow.ComponentSpatial.Rect.Moved(v)
-	} else {
-		arrow.ComponentSpatial.Rect = s.player.ComponentSpatial.Rect
 	}
 }
 
-func (s *SystemSpatial) movePlayer() {
-	player := s.player
+func (s *SystemSpatial) movePlayer(player Entity, dt float64) {
 	speed := player.ComponentMovement.Speed
 	if player.ComponentDash.Charge == player.ComponentDash.MaxCharge {
 		speed += player.ComponentDash.SpeedMod
 	}
 	if speed > 0 {
-		v := delta(player.ComponentMovement.Direction, speed, speed)
+		v := delta(player.ComponentMovement.Direction, speed*dt, speed*dt)
 		player.ComponentSpatial.PrevRect = player.ComponentSpatial.Rect
 		player.ComponentSpatial.Rect = player.ComponentSpatial.Rect.Moved(v)
 	}
 }
 
-func (s *SystemSpatial) moveMoveableObstacle(entity *spatialEntity) {
+func (s *SystemSpatial) moveMoveableObstacle(entity Entity, dt float64) {
 	if entity.ComponentMovement.RemainingMoves > 0 {
 		speed := entity.ComponentMovement.MaxSpeed
 		entity.ComponentSpatial.PrevRect = entity.ComponentSpatial.Rect
-		moveVec := delta(entity.ComponentMovement.Direction, speed, speed)
+		moveVec := delta(entity.ComponentMovement.Direction, speed*dt, speed*dt)
 		entity.ComponentSpatial.Rect = entity.ComponentSpatial.Rect.Moved(moveVec)
 		entity.ComponentMovement.RemainingMoves--
 	} else {
@@ -246,11 +214,12 @@ func (s *SystemSpatial) moveMoveableObstacle(entity *spatialEntity) {
 	}
 }
 
-func (s *SystemSpatial) moveEnemyRandom(enemy *spatialEntity) {
+func (s *SystemSpatial) moveEnemyRandom(enemy Entity, dt float64) {
 	if enemy.ComponentMovement.RemainingMoves == 0 {
 		enemy.ComponentMovement.MovingFromHit = false
-		enemy.ComponentMovement.RemainingMoves = s.Rand.Intn(enemy.ComponentMovement.MaxMoves)
-		enemy.ComponentMovement.Direction = RandomDirection(s.Rand)
+		random := &s.SystemsManager.Random
+		enemy.ComponentMovement.RemainingMoves = random.Intn(enemy.ComponentMovement.MaxMoves)
+		enemy.ComponentMovement.Direction = RandomDirection(random)
 	} else if enemy.ComponentMovement.RemainingMoves > 0 {
 		var speed float64
 		if enemy.ComponentMovement.MovingFromHit {
@@ -259,7 +228,7 @@ func (s *SystemSpatial) moveEnemyRandom(enemy *spatialEntity) {
 			speed = enemy.ComponentMovement.MaxSpeed
 		}
 		enemy.ComponentSpatial.PrevRect = enemy.ComponentSpatial.Rect
-		moveVec := delta(enemy.ComponentMovement.Direction, speed, speed)
+		moveVec := delta(enemy.ComponentMovement.Direction, speed*dt, speed*dt)
 		enemy.ComponentSpatial.Rect = enemy.ComponentSpatial.Rect.Moved(moveVec)
 		enemy.ComponentMovement.RemainingMoves--
 	} else {
@@ -268,7 +237,7 @@ func (s *SystemSpatial) moveEnemyRandom(enemy *spatialEntity) {
 	}
 }
 
-func (s *SystemSpatial) moveEnemyLeftRight(enemy *spatialEntity) {
+func (s *SystemSpatial) moveEnemyLeftRight(enemy Entity, dt float64) {
 	if enemy.ComponentMovement.RemainingMoves == 0 {
 		enemy.ComponentMovement.MovingFromHit = false
 		enemy.ComponentMovement.RemainingMoves = enemy.ComponentMovement.MaxMoves
@@ -286,7 +255,7 @@ func (s *SystemSpatial) moveEnemyLeftRight(enemy *spatialEntity) {
 			speed = enemy.ComponentMovement.MaxSpeed
 		}
 		enemy.ComponentSpatial.PrevRect = enemy.ComponentSpatial.Rect
-		moveVec := delta(enemy.ComponentMovement.Direction, speed, speed)
+		moveVec := delta(enemy.ComponentMovement.Direction, speed*dt, speed*dt)
 		enemy.ComponentSpatial.Rect = enemy.ComponentSpatial.Rect.Moved(moveVec)
 		enemy.ComponentMovement.RemainingMoves--
 	} else {
@@ -0,0 +1,41 @@
+package zelduh
+
+import (
+	"testing"
+
+	"github.com/faiface/pixel"
+)
+
+func TestSystemSpatialAttachedSword(t *testing.T) {
+	w := NewSystemsManager(1)
+	tileSize := 48.0
+	player := BuildEntityFromConfig(MustGetPreset("player")(tileSize, 5, 4), w.NewEntityID(), 5)
+	sword := BuildEntityFromConfig(MustGetPreset("sword")(tileSize, 0, 0), w.NewEntityID(), 5)
+	sword.ComponentAttachment.Attach(player.ID())
+	w.AddEntities(player, sword)
+	spatial := &SystemSpatial{SystemsManager: &w, TileSize: tileSize}
+	player.ComponentMovement.Direction = DirectionRight
+
+	spatial.Update(testDt)
+	if got, want := sword.ComponentSpatial.Rect, player.ComponentSpatial.Rect; got != want {
+		t.Errorf("idle sword rect = %v, want the player's %v", got, want)
+	}
+	if sword.ComponentMovement.Direction != DirectionRight {
+		t.Errorf("sword faces %s, want %s", sword.ComponentMovement.Direction, DirectionRight)
+	}
+
+	sword.ComponentIgnore.Value = false
+	spatial.Update(testDt)
+	if got, want := sword.ComponentSpatial.Rect, player.ComponentSpatial.Rect.Moved(pixel.V(tileSize, 0)); got != want {
+		t.Errorf("swung sword rect = %v, want %v", got, want)
+	}
+
+	skull := BuildEntityFromConfig(MustGetPreset("skull")(tileSize, 2, 2), w.NewEntityID(), 5)
+	w.AddEntity(skull)
+	sword.ComponentAttachment.Attach(skull.ID())
+	w.DestroyEntity(skull.ID())
+	spatial.Update(testDt)
+	if _, ok := sword.ComponentAttachment.Parent(); ok {
+		t.Error("sword still attached to a destroyed entity")
+	}
+}
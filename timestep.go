@@ -0,0 +1,42 @@
+package zelduh
+
+// FixedTimestep turns variable frame times into a whole number of fixed-length simulation ticks
+//
+// Frame time that does not add up to a full tick is carried over to the next frame.
+type FixedTimestep struct {
+	// Step is the length of one tick, in seconds
+	Step float64
+	// MaxFrameTime caps the frame time passed to Advance, so one long frame
+	// (e.g. the window being dragged) can not trigger a burst of catch-up ticks
+	MaxFrameTime float64
+	accumulator  float64
+}
+
+// NewFixedTimestep returns a FixedTimestep that runs ticksPerSecond ticks per second
+func NewFixedTimestep(ticksPerSecond float64) FixedTimestep {
+	return FixedTimestep{
+		Step:         1 / ticksPerSecond,
+		MaxFrameTime: 0.25,
+	}
+}
+
+// Advance adds the frame time, in seconds, and returns how many ticks to run
+func (t *FixedTimestep) Advance(frameTime float64) int {
+	if frameTime > t.MaxFrameTime {
+		frameTime = t.MaxFrameTime
+	}
+	if frameTime > 0 {
+		t.accumulator += frameTime
+	}
+	ticks := 0
+	for t.accumulator >= t.Step {
+		t.accumulator -= t.Step
+		ticks++
+	}
+	return ticks
+}
+
+// Alpha returns how far, from 0 to 1, the current frame is between the last tick and the next one
+func (t *FixedTimestep) Alpha() float64 {
+	return t.accumulator / t.Step
+}
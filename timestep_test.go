@@ -0,0 +1,34 @@
+package zelduh
+
+import "testing"
+
+func TestFixedTimestepAdvance(t *testing.T) {
+	// Steps of 1/8s add up exactly, so the expected ticks and alphas are exact
+	tests := []struct {
+		name       string
+		frameTimes []float64
+		wantTicks  []int
+		wantAlpha  float64
+	}{
+		{"one step", []float64{0.125}, []int{1}, 0},
+		{"several steps", []float64{0.25}, []int{2}, 0},
+		{"part of a step", []float64{0.0625}, []int{0}, 0.5},
+		{"parts carried over", []float64{0.0625, 0.0625, 0.1875}, []int{0, 1, 1}, 0.5},
+		{"long frame clamped", []float64{1}, []int{2}, 0},
+		{"clamped time not carried over", []float64{1, 0.0625}, []int{2, 0}, 0.5},
+		{"negative frame ignored", []float64{0.0625, -1}, []int{0, 0}, 0.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timestep := NewFixedTimestep(8)
+			for i, frameTime := range tt.frameTimes {
+				if got := timestep.Advance(frameTime); got != tt.wantTicks[i] {
+					t.Errorf("Advance(%v) #%d = %d, want %d", frameTime, i, got, tt.wantTicks[i])
+				}
+			}
+			if got := timestep.Alpha(); got != tt.wantAlpha {
+				t.Errorf("Alpha = %v, want %v", got, tt.wantAlpha)
+			}
+		})
+	}
+}
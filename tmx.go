@@ -1,8 +1,11 @@
 package zelduh
 
 import (
+	"encoding/xml"
 	"fmt"
 	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 
@@ -10,83 +13,345 @@ import (
 	"github.com/faiface/pixel"
 )
 
-// Load loads the tmx files
-func Load(tilemapFiles []string, tilemapDir string) map[string]tmxreader.TmxMap {
-	tmxMapData := map[string]tmxreader.TmxMap{}
-	for _, name := range tilemapFiles {
-		path := fmt.Sprintf("%s%s.tmx", tilemapDir, name)
-		tmxMapData[name] = parseTmxFile(path)
-	}
-	return tmxMapData
-}
+func parseTmxFile(filename string) (tmxreader.TmxMap, tmxExtras, error) {
+	var extras tmxExtras
 
-func parseTmxFile(filename string) tmxreader.TmxMap {
 	raw, err := ioutil.ReadFile(filename)
 	if err != nil {
-		panic(err)
+		return tmxreader.TmxMap{}, extras, err
 	}
 
 	tmxMap, err := tmxreader.Parse(raw)
 	if err != nil {
-		panic(err)
+		return tmxMap, extras, fmt.Errorf("%s: %w", filename, err)
 	}
 
-	return tmxMap
+	if err := xml.Unmarshal(raw, &extras); err != nil {
+		return tmxMap, extras, fmt.Errorf("%s: %w", filename, err)
+	}
+
+	return tmxMap, extras, nil
+}
+
+// tmxExtras holds the parts of a TMX map that tmxreader does not read
+//
+// tmxreader reads object layers without object names, custom properties or
+// fractional positions, and tilesets without tile properties, so they are
+// read separately.
+type tmxExtras struct {
+	ObjectGroups []tmxObjectGroup `xml:"objectgroup"`
+	Tilesets     []tmxTileset     `xml:"tileset"`
+}
+
+// tmxTileset is a tileset of a TMX map, either embedded in the map or in the
+// TSX file named by Source
+type tmxTileset struct {
+	FirstGID int       `xml:"firstgid,attr"`
+	Source   string    `xml:"source,attr"`
+	Tiles    []tmxTile `xml:"tile"`
+}
+
+type tmxTile struct {
+	ID         int                     `xml:"id,attr"`
+	Properties []tmxreader.TmxProperty `xml:"properties>property"`
+}
+
+type tmxObjectGroup struct {
+	Name    string      `xml:"name,attr"`
+	Objects []tmxObject `xml:"object"`
+}
+
+type tmxObject struct {
+	ID         int                     `xml:"id,attr"`
+	Name       string                  `xml:"name,attr"`
+	Type       string                  `xml:"type,attr"`
+	Class      string                  `xml:"class,attr"`
+	GID        int                     `xml:"gid,attr"`
+	X          float64                 `xml:"x,attr"`
+	Y          float64                 `xml:"y,attr"`
+	Width      float64                 `xml:"width,attr"`
+	Height     float64                 `xml:"height,attr"`
+	Properties []tmxreader.TmxProperty `xml:"properties>property"`
 }
 
+// preset returns the name of the preset the object places; Tiled 1.9 renamed
+// an object's type to its class
+func (o tmxObject) preset() string {
+	if o.Type != "" {
+		return o.Type
+	}
+	return o.Class
+}
+
+// mapDrawData is one tile of a map
+//
+// Collides is true if the tile blocks movement. Terrain is the tile's terrain,
+// such as "water", or empty.
 type mapDrawData struct {
 	Rect     pixel.Rect
 	SpriteID int
+	Collides bool
+	Terrain  string
 }
 
 // MapData represents data for one map
+//
+// Width and Height are the size of the map in tiles, from its TMX header, and
+// Bounds is its rectangle in room coordinates. EntityConfigs are the entities
+// placed in the map's object layers, in room coordinates.
 type MapData struct {
-	Name string
-	Data []mapDrawData
+	Name          string
+	Width, Height int
+	Bounds        pixel.Rect
+	Data          []mapDrawData
+	EntityConfigs []EntityConfig
 }
 
-// BuildMapDrawData builds draw data and stores it in a map
-func BuildMapDrawData(dir string, files []string, tileSize float64) map[string]MapData {
+// buildMapData builds the draw data of one map
+//
+// tiles holds the properties of the map's tiles by sprite ID. A tile whose
+// properties do not set collides blocks movement unless its sprite is in
+// NonObstacleSprites.
+func buildMapData(mapName string, mapData tmxreader.TmxMap, tileSize float64, tiles map[int]tileProperties) (MapData, error) {
+	width, height := mapData.Width, mapData.Height
+	md := MapData{
+		Name:   mapName,
+		Width:  width,
+		Height: height,
+		Bounds: pixel.R(0, 0, float64(width)*tileSize, float64(height)*tileSize),
+		Data:   []mapDrawData{},
+	}
+
+	for _, layer := range mapData.Layers {
+		// Tiled ends every row but the last with a comma, so one is added to
+		// the last row, and each row has an empty field after its tiles
+		records, err := ParseCSV(strings.TrimSpace(layer.Data.Value) + ",")
+		if err != nil {
+			return md, fmt.Errorf("layer %q: %w", layer.Name, err)
+		}
+		if len(records) != height {
+			return md, fmt.Errorf("layer %q has %d rows, want %d", layer.Name, len(records), height)
+		}
+		for row, record := range records {
+			if len(record) < width {
+				return md, fmt.Errorf("layer %q row %d has %d tiles, want %d", layer.Name, row, len(record), width)
+			}
+			// Rows run from the top of the map down
+			y := float64(height-1-row) * tileSize
+			for col := 0; col < width; col++ {
+				x := float64(col) * tileSize
 
-	// load all TMX file data for each map
-	tmxMapData := Load(files, dir)
+				spriteID, err := strconv.Atoi(strings.TrimSpace(record[col]))
+				if err != nil {
+					return md, fmt.Errorf("layer %q row %d column %d: %w", layer.Name, row, col, err)
+				}
+				_, nonObstacle := NonObstacleSprites[spriteID]
+				mrd := mapDrawData{
+					Rect:     pixel.R(x, y, x+tileSize, y+tileSize),
+					SpriteID: spriteID,
+					Collides: !nonObstacle,
+				}
+				if properties, ok := tiles[spriteID]; ok {
+					if properties.collidesSet {
+						mrd.Collides = properties.collides
+					}
+					mrd.Terrain = properties.terrain
+				}
+				md.Data = append(md.Data, mrd)
+			}
+		}
+	}
 
-	all := map[string]MapData{}
+	return md, nil
+}
 
-	for mapName, mapData := range tmxMapData {
+// tileProperties are the properties of a tileset tile that the game reads
+type tileProperties struct {
+	collides    bool
+	collidesSet bool
+	terrain     string
+}
 
-		md := MapData{
-			Name: mapName,
-			Data: []mapDrawData{},
+// parseTileProperties reads the collides and terrain properties of a tile;
+// other properties are ignored, as Tiled tilesets are shared with other tools
+func parseTileProperties(properties []tmxreader.TmxProperty) (tileProperties, error) {
+	var p tileProperties
+	for _, property := range properties {
+		switch property.Name {
+		case "collides":
+			collides, err := strconv.ParseBool(property.Value)
+			if err != nil {
+				return p, fmt.Errorf("property %s: %w", property.Name, err)
+			}
+			p.collides = collides
+			p.collidesSet = true
+		case "terrain":
+			p.terrain = property.Value
 		}
+	}
+	return p, nil
+}
 
-		layers := mapData.Layers
-		for _, layer := range layers {
-
-			records := ParseCSV(strings.TrimSpace(layer.Data.Value) + ",")
-			for row := 0; row <= len(records); row++ {
-				if len(records) > row {
-					for col := 0; col < len(records[row])-1; col++ {
-						y := float64(11-row) * tileSize
-						x := float64(col) * tileSize
-
-						record := records[row][col]
-						spriteID, err := strconv.Atoi(record)
-						if err != nil {
-							panic(err)
-						}
-						mrd := mapDrawData{
-							Rect:     pixel.R(x, y, x+tileSize, y+tileSize),
-							SpriteID: spriteID,
-						}
-						md.Data = append(md.Data, mrd)
-					}
-				}
+// parseTsxFile reads the tile properties of a TSX tileset, by tile ID
+func parseTsxFile(filename string) (map[int]tileProperties, error) {
+	raw, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var tileset tmxTileset
+	if err := xml.Unmarshal(raw, &tileset); err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	tiles, err := tilesetProperties(tileset.Tiles)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return tiles, nil
+}
+
+func tilesetProperties(tmxTiles []tmxTile) (map[int]tileProperties, error) {
+	tiles := map[int]tileProperties{}
+	for _, tile := range tmxTiles {
+		properties, err := parseTileProperties(tile.Properties)
+		if err != nil {
+			return nil, fmt.Errorf("tile %d: %w", tile.ID, err)
+		}
+		tiles[tile.ID] = properties
+	}
+	return tiles, nil
+}
+
+// mapTileProperties returns the properties of the tiles of a map's tilesets,
+// by sprite ID
+//
+// External tilesets are read with readTsx, from paths relative to the map's
+// directory. A map may reference a TSX file that does not exist; its tiles
+// fall back to NonObstacleSprites.
+func mapTileProperties(mapDir string, tilesets []tmxTileset, readTsx func(path string) (map[int]tileProperties, error)) (map[int]tileProperties, error) {
+	tiles := map[int]tileProperties{}
+	for _, tileset := range tilesets {
+		var byID map[int]tileProperties
+		var err error
+		if tileset.Source != "" {
+			byID, err = readTsx(filepath.Join(mapDir, tileset.Source))
+			if os.IsNotExist(err) {
+				continue
+			}
+		} else {
+			byID, err = tilesetProperties(tileset.Tiles)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("tileset %d: %w", tileset.FirstGID, err)
+		}
+		for id, properties := range byID {
+			tiles[tileset.FirstGID+id] = properties
+		}
+	}
+	return tiles, nil
+}
 
+// buildMapEntityConfigs builds an EntityConfig for every object in the map's
+// object layers
+//
+// An object's type names the preset it places, and its custom properties
+// override fields of the preset's config; see objectPropertyOverride. The
+// object is placed by its bottom left corner. Two objects with the same name
+// are an error, and so is a warp to a room that is not in rooms, unless rooms
+// is nil.
+func buildMapEntityConfigs(tmxMap tmxreader.TmxMap, layers tmxExtras, tileSize float64, rooms map[RoomID]bool) ([]EntityConfig, error) {
+	configs := []EntityConfig{}
+	if tmxMap.TileWidth == 0 || tmxMap.TileHeight == 0 {
+		return configs, nil
+	}
+	scaleX := tileSize / float64(tmxMap.TileWidth)
+	scaleY := tileSize / float64(tmxMap.TileHeight)
+	mapHeight := float64(tmxMap.Height * tmxMap.TileHeight)
+	names := map[string]int{}
+
+	for _, group := range layers.ObjectGroups {
+		for _, object := range group.Objects {
+			preset, err := GetPreset(object.preset())
+			if err != nil {
+				return nil, fmt.Errorf("object %d in layer %q: %w", object.ID, group.Name, err)
+			}
+
+			// Tiled measures y down from the top of the map, to the top of a
+			// rectangle object, or to the bottom of a tile object
+			bottom := object.Y + object.Height
+			if object.GID != 0 {
+				bottom = object.Y
+			}
+			x := object.X * scaleX / tileSize
+			y := (mapHeight - bottom) * scaleY / tileSize
+
+			overrides := []PresetOverride{}
+			if object.Name != "" {
+				overrides = append(overrides, WithName(object.Name))
+			}
+			for _, property := range object.Properties {
+				override, err := objectPropertyOverride(property.Name, property.Value)
+				if err != nil {
+					return nil, fmt.Errorf("object %d in layer %q: %w", object.ID, group.Name, err)
+				}
+				overrides = append(overrides, override)
+			}
+			config := preset(tileSize, x, y).With(overrides...)
+			if other, ok := names[config.Name]; ok && config.Name != "" {
+				return nil, fmt.Errorf("object %d in layer %q: name %q is taken by object %d", object.ID, group.Name, config.Name, other)
 			}
-			all[mapName] = md
+			if rooms != nil && config.Category == CategoryWarp && !rooms[config.WarpToRoomID] {
+				return nil, fmt.Errorf("object %d in layer %q: warp to room %d, which does not exist", object.ID, group.Name, config.WarpToRoomID)
+			}
+			names[config.Name] = object.ID
+			configs = append(configs, config)
 		}
 	}
+	return configs, nil
+}
 
-	return all
+// objectPropertyOverride returns the override for a custom property of a map object
+//
+// The properties are health, speed (pixels per second), pattern, warpTo (a
+// room ID), hitboxRadius, name and tags (comma separated).
+func objectPropertyOverride(name, value string) (PresetOverride, error) {
+	switch name {
+	case "health":
+		health, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("property %s: %w", name, err)
+		}
+		return WithHealth(health), nil
+	case "speed":
+		speed, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("property %s: %w", name, err)
+		}
+		return WithSpeed(speed), nil
+	case "pattern":
+		return WithPattern(value), nil
+	case "warpTo":
+		roomID, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("property %s: %w", name, err)
+		}
+		return WithWarpTo(RoomID(roomID)), nil
+	case "hitboxRadius":
+		radius, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("property %s: %w", name, err)
+		}
+		return WithHitboxRadius(radius), nil
+	case "name":
+		return WithName(value), nil
+	case "tags":
+		tags := []string{}
+		for _, tag := range strings.Split(value, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+		return WithTags(tags...), nil
+	default:
+		return nil, fmt.Errorf("unknown property %q", name)
+	}
 }
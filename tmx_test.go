@@ -0,0 +1,182 @@
+package zelduh
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/deanobob/tmxreader"
+	"github.com/faiface/pixel"
+)
+
+func TestBuildMapData(t *testing.T) {
+	// A 3x2 map, with rows from the top down as Tiled writes them
+	tmxMap := tmxreader.TmxMap{
+		Width: 3, Height: 2, TileWidth: 16, TileHeight: 16,
+		Layers: []tmxreader.TmxLayer{{
+			Name: "ground",
+			Data: tmxreader.TmxData{Encoding: "csv", Value: "\n1,86,2,\n3,4,0\n"},
+		}},
+	}
+	tiles := map[int]tileProperties{
+		2: {collides: false, collidesSet: true, terrain: "water"},
+	}
+
+	md, err := buildMapData("small", tmxMap, 48, tiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if md.Name != "small" || md.Width != 3 || md.Height != 2 {
+		t.Errorf("map %q is %dx%d, want small 3x2", md.Name, md.Width, md.Height)
+	}
+	if want := pixel.R(0, 0, 144, 96); md.Bounds != want {
+		t.Errorf("Bounds = %v, want %v", md.Bounds, want)
+	}
+
+	want := []mapDrawData{
+		{Rect: pixel.R(0, 48, 48, 96), SpriteID: 1, Collides: true},
+		{Rect: pixel.R(48, 48, 96, 96), SpriteID: 86, Collides: false},
+		{Rect: pixel.R(96, 48, 144, 96), SpriteID: 2, Collides: false, Terrain: "water"},
+		{Rect: pixel.R(0, 0, 48, 48), SpriteID: 3, Collides: true},
+		{Rect: pixel.R(48, 0, 96, 48), SpriteID: 4, Collides: true},
+		{Rect: pixel.R(96, 0, 144, 48), SpriteID: 0, Collides: true},
+	}
+	if len(md.Data) != len(want) {
+		t.Fatalf("got %d tiles, want %d", len(md.Data), len(want))
+	}
+	for i := range want {
+		if md.Data[i] != want[i] {
+			t.Errorf("tile %d = %+v, want %+v", i, md.Data[i], want[i])
+		}
+	}
+}
+
+func TestBuildMapDataRejectsWrongSize(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{"too few rows", "1,1,1", `layer "ground" has 1 rows, want 2`},
+		{"short rows", "1,\n1", `layer "ground" row 0 has 2 tiles, want 3`},
+		{"not a number", "1,x,1,\n1,1,1", `layer "ground" row 0 column 1`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmxMap := tmxreader.TmxMap{
+				Width: 3, Height: 2, TileWidth: 16, TileHeight: 16,
+				Layers: []tmxreader.TmxLayer{{Name: "ground", Data: tmxreader.TmxData{Value: tt.data}}},
+			}
+			_, err := buildMapData("small", tmxMap, 48, nil)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMapStoreLargeMap(t *testing.T) {
+	md, err := NewMapStore("assets/tilemaps/", 48).Get("arena")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if md.Width != 28 || md.Height != 24 {
+		t.Errorf("arena is %dx%d, want 28x24", md.Width, md.Height)
+	}
+	if want := pixel.R(0, 0, 1344, 1152); md.Bounds != want {
+		t.Errorf("Bounds = %v, want %v", md.Bounds, want)
+	}
+	// Two layers of 28x24 tiles
+	if got := len(md.Data); got != 2*28*24 {
+		t.Errorf("got %d tiles, want %d", got, 2*28*24)
+	}
+	if got := len(md.EntityConfigs); got != 3 {
+		t.Errorf("got %d entities, want 3", got)
+	}
+}
+
+func TestBuildMapEntityConfigsNames(t *testing.T) {
+	tmxMap := tmxreader.TmxMap{Width: 14, Height: 12, TileWidth: 16, TileHeight: 16}
+	skull := func(id int, name string, properties ...tmxreader.TmxProperty) tmxObject {
+		return tmxObject{ID: id, Name: name, Type: "skull", Width: 16, Height: 16, Properties: properties}
+	}
+
+	tests := []struct {
+		name    string
+		objects []tmxObject
+		wantErr string
+	}{
+		{"unnamed objects", []tmxObject{skull(1, ""), skull(2, "")}, ""},
+		{"different names", []tmxObject{skull(1, "a"), skull(2, "b")}, ""},
+		{"same name", []tmxObject{skull(1, "boss"), skull(2, "boss")}, `object 2 in layer "enemies": name "boss" is taken by object 1`},
+		{"same name from a property", []tmxObject{skull(1, "boss"), skull(2, "", tmxreader.TmxProperty{Name: "name", Value: "boss"})}, `name "boss" is taken by object 1`},
+		{"renamed by a property", []tmxObject{skull(1, "boss"), skull(2, "boss", tmxreader.TmxProperty{Name: "name", Value: "minion"})}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			layers := tmxExtras{ObjectGroups: []tmxObjectGroup{{Name: "enemies", Objects: tt.objects}}}
+			configs, err := buildMapEntityConfigs(tmxMap, layers, 48, nil)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(configs) != len(tt.objects) {
+					t.Errorf("got %d configs, want %d", len(configs), len(tt.objects))
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBuildMapEntityConfigsWarps(t *testing.T) {
+	tmxMap := tmxreader.TmxMap{Width: 14, Height: 12, TileWidth: 16, TileHeight: 16}
+	warp := func(id int, properties ...tmxreader.TmxProperty) tmxObject {
+		return tmxObject{ID: id, Type: "warp", Width: 16, Height: 16, Properties: properties}
+	}
+	warpTo := func(roomID string) tmxreader.TmxProperty {
+		return tmxreader.TmxProperty{Name: "warpTo", Value: roomID}
+	}
+	rooms := map[RoomID]bool{1: true, 2: true}
+
+	tests := []struct {
+		name    string
+		objects []tmxObject
+		rooms   map[RoomID]bool
+		wantErr string
+	}{
+		{"known rooms", []tmxObject{warp(1, warpTo("1")), warp(2, warpTo("2"))}, rooms, ""},
+		{"unknown room", []tmxObject{warp(1, warpTo("1")), warp(2, warpTo("7"))}, rooms, `object 2 in layer "entities": warp to room 7, which does not exist`},
+		{"no warpTo", []tmxObject{warp(1)}, rooms, `warp to room 0, which does not exist`},
+		{"rooms not set", []tmxObject{warp(1, warpTo("7"))}, nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			layers := tmxExtras{ObjectGroups: []tmxObjectGroup{{Name: "entities", Objects: tt.objects}}}
+			configs, err := buildMapEntityConfigs(tmxMap, layers, 48, tt.rooms)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(configs) != len(tt.objects) {
+					t.Errorf("got %d configs, want %d", len(configs), len(tt.objects))
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMapStoreRejectsUnknownWarp(t *testing.T) {
+	maps := NewMapStore("assets/tilemaps/", 48)
+	maps.SetRooms(Rooms{1: NewRoom("rockWithCaveEntrance")})
+	_, err := maps.Get("rockWithCaveEntrance")
+	if err == nil || !strings.Contains(err.Error(), "rockWithCaveEntrance.tmx: object 1 in layer \"entities\": warp to room 11") {
+		t.Errorf("err = %v, want the warp to room 11", err)
+	}
+}
@@ -23,10 +23,12 @@ type RoomTransitionManager struct {
 	transition *RoomTransition
 }
 
-func NewRoomTransitionManager() RoomTransitionManager {
+// NewRoomTransitionManager returns a new RoomTransitionManager whose slides
+// take config.SlideTicks ticks
+func NewRoomTransitionManager(config Config) RoomTransitionManager {
 	return RoomTransitionManager{
 		transition: &RoomTransition{
-			Start: float64(TileSize),
+			Start: float64(config.SlideTicks),
 		},
 	}
 }
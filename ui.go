@@ -3,7 +3,6 @@ package zelduh
 import (
 	"fmt"
 	"image/color"
-	"os"
 
 	"github.com/faiface/pixel"
 	"github.com/faiface/pixel/imdraw"
@@ -13,16 +12,12 @@ import (
 )
 
 type UI struct {
-	Window *pixelgl.Window
+	Window Window
 	Text   *text.Text
 }
 
-func NewUI(currLocaleMsgs LocaleMessagesMap, windowConfig WindowConfig) UI {
-
-	// Initialize text
-	orig := pixel.V(20, 50)
-	txt := text.New(orig, text.Atlas7x13)
-	txt.Color = colornames.Black
+// NewUI builds a UI backed by a new pixelgl window
+func NewUI(currLocaleMsgs LocaleMessagesMap, windowConfig WindowConfig) (UI, error) {
 
 	// Initialize window
 	win, err := pixelgl.NewWindow(
@@ -33,25 +28,56 @@ func NewUI(currLocaleMsgs LocaleMessagesMap, windowConfig WindowConfig) UI {
 		},
 	)
 	if err != nil {
-		fmt.Println("Initializing GUI window failed:")
-		fmt.Println(err)
-		os.Exit(1)
+		return UI{}, fmt.Errorf("initializing window: %w", err)
 	}
 
+	return NewUIFromWindow(win), nil
+}
+
+// NewHeadlessUI builds a UI backed by a HeadlessWindow, so the game can run without a display
+func NewHeadlessUI(windowConfig WindowConfig) (UI, *HeadlessWindow) {
+	win := NewHeadlessWindow(pixel.R(windowConfig.X, windowConfig.Y, windowConfig.Width, windowConfig.Height))
+	return NewUIFromWindow(win), win
+}
+
+// NewUIFromWindow builds a UI backed by an existing Window
+func NewUIFromWindow(win Window) UI {
+
+	// Initialize text
+	orig := pixel.V(20, 50)
+	txt := text.New(orig, text.Atlas7x13)
+	txt.Color = colornames.Black
+
 	return UI{
 		Window: win,
 		Text:   txt,
 	}
 }
 
-func DrawCenterText(win *pixelgl.Window, txt *text.Text, s string, c color.RGBA) {
+func DrawCenterText(win Renderer, txt *text.Text, s string, c color.RGBA) {
 	txt.Clear()
 	txt.Color = c
 	fmt.Fprintln(txt, s)
 	txt.Draw(win, pixel.IM.Moved(win.Bounds().Center().Sub(txt.Bounds().Center())))
 }
 
-func DrawMapBackground(win *pixelgl.Window, mapConfig MapConfig, color color.Color) {
+// DrawProfileOverlay draws the profiler report in the top left corner of the window
+func DrawProfileOverlay(win Renderer, txt *text.Text, report ProfileReport) {
+	txt.Clear()
+	txt.Color = colornames.Black
+	fmt.Fprintf(txt, "frame ms p50 %.2f  p95 %.2f  p99 %.2f  max %.2f\n",
+		report.FrameMsP50, report.FrameMsP95, report.FrameMsP99, report.FrameMsMax)
+	fmt.Fprintf(txt, "allocs/frame %.0f\n", report.AllocsPerFrame)
+	for _, system := range report.Systems {
+		fmt.Fprintf(txt, "%-10s update %.3f ms  draw %.3f ms  entities %d\n",
+			system.Name, system.UpdateMsLast, system.DrawMsLast, system.Entities)
+	}
+	bounds := txt.Bounds()
+	v := pixel.V(10-bounds.Min.X, win.Bounds().Max.Y-10-bounds.Max.Y)
+	txt.Draw(win, pixel.IM.Moved(v))
+}
+
+func DrawMapBackground(win Renderer, mapConfig MapConfig, color color.Color) {
 	s := imdraw.New(nil)
 	s.Color = color
 	s.Push(pixel.V(mapConfig.X, mapConfig.Y))
@@ -60,40 +86,55 @@ func DrawMapBackground(win *pixelgl.Window, mapConfig MapConfig, color color.Col
 	s.Draw(win)
 }
 
-func DrawScreenStart(win *pixelgl.Window, txt *text.Text, currLocaleMsgs LocaleMessagesMap, mapConfig MapConfig) {
+func DrawScreenStart(win Renderer, txt *text.Text, currLocaleMsgs LocaleMessagesMap, mapConfig MapConfig) {
 	win.Clear(colornames.Darkgray)
 	DrawMapBackground(win, mapConfig, colornames.White)
 	DrawCenterText(win, txt, currLocaleMsgs["gameTitle"], colornames.Black)
 }
 
-func DrawMapBackgroundImage(
-	win *pixelgl.Window,
-	spritesheet map[int]*pixel.Sprite,
-	allMapDrawData map[string]MapData,
-	name string,
-	modX, modY float64,
-	mapConfig MapConfig,
-) {
+// DrawScreenLoading draws the message and a bar filled in proportion to done out of total
+func DrawScreenLoading(win Renderer, txt *text.Text, message string, done, total int, mapConfig MapConfig) {
+	win.Clear(colornames.Darkgray)
+	DrawMapBackground(win, mapConfig, colornames.White)
+	DrawCenterText(win, txt, message, colornames.Black)
 
-	d := allMapDrawData[name]
-	for _, spriteData := range d.Data {
-		if spriteData.SpriteID != 0 {
-			sprite := spritesheet[spriteData.SpriteID]
+	if total == 0 {
+		return
+	}
+	width := mapConfig.Width / 2
+	min := pixel.V(mapConfig.X+(mapConfig.Width-width)/2, mapConfig.Y+mapConfig.Height/3)
 
-			vec := spriteData.Rect.Min
+	s := imdraw.New(nil)
+	s.Color = colornames.Black
+	s.Push(min, min.Add(pixel.V(width, 10)))
+	s.Rectangle(1)
+	s.Push(min, min.Add(pixel.V(width*float64(done)/float64(total), 10)))
+	s.Rectangle(0)
+	s.Draw(win)
+}
 
-			movedVec := pixel.V(
-				vec.X+mapConfig.X+modX+TileSize/2,
-				vec.Y+mapConfig.Y+modY+TileSize/2,
-			)
-			matrix := pixel.IM.Moved(movedVec)
+// DrawMapBackgroundImage draws the tiles of the map, transformed from room
+// coordinates to the screen by view
+func DrawMapBackgroundImage(
+	win Renderer,
+	spritesheet *Spritesheet,
+	mapData MapData,
+	view pixel.Matrix,
+) {
+	for _, spriteData := range mapData.Data {
+		if spriteData.SpriteID != 0 {
+			sprite, ok := spritesheet.Sprite(spriteData.SpriteID)
+			if !ok {
+				continue
+			}
+			matrix := pixel.IM.Moved(spriteData.Rect.Center()).Chained(view)
 			sprite.Draw(win, matrix)
 		}
 	}
 }
 
-func AddUICoin(systemsManager *SystemsManager, frameRate int) {
-	coin := BuildEntityFromConfig(GetPreset("uiCoin")(4, 14), systemsManager.NewEntityID(), frameRate)
+func AddUICoin(systemsManager *SystemsManager, config Config) {
+	coin := BuildEntityFromConfig(MustGetPreset("uiCoin")(config.TileSize, 4, 14), systemsManager.NewEntityID(), config.FrameRate)
 	systemsManager.AddEntity(coin)
 }
 
@@ -108,40 +149,40 @@ func AddUIHearts(systemsManager *SystemsManager, hearts []Entity, health int) {
 	}
 }
 
+// DrawObstaclesPerMapTiles builds an obstacle entity, in room coordinates, for
+// every tile of the room's map that blocks movement
 func DrawObstaclesPerMapTiles(
 	systemsManager *SystemsManager,
 	roomsMap Rooms,
-	allMapDrawData map[string]MapData,
+	maps *MapStore,
 	roomID RoomID,
-	modX,
-	modY float64,
-	mapConfig MapConfig,
-	frameRate int,
-) []Entity {
-	d := allMapDrawData[roomsMap[roomID].MapName()]
+	config Config,
+) ([]Entity, error) {
+	tileSize := config.TileSize
+	room, err := roomsMap.room(roomID)
+	if err != nil {
+		return nil, err
+	}
+	d, err := maps.Get(room.MapName())
+	if err != nil {
+		return nil, err
+	}
 	obstacles := []Entity{}
-	mod := 0.5
 	for _, spriteData := range d.Data {
 		if spriteData.SpriteID != 0 {
-			vec := spriteData.Rect.Min
-			movedVec := pixel.V(
-				vec.X+mapConfig.X+modX+TileSize/2,
-				vec.Y+mapConfig.Y+modY+TileSize/2,
-			)
-
-			if _, ok := NonObstacleSprites[spriteData.SpriteID]; !ok {
-				x := movedVec.X/TileSize - mod
-				y := movedVec.Y/TileSize - mod
+			if spriteData.Collides {
+				x := spriteData.Rect.Min.X / tileSize
+				y := spriteData.Rect.Min.Y / tileSize
 				id := systemsManager.NewEntityID()
-				obstacle := BuildEntityFromConfig(GetPreset("obstacle")(x, y), id, frameRate)
+				obstacle := BuildEntityFromConfig(MustGetPreset("obstacle")(tileSize, x, y), id, config.FrameRate)
 				obstacles = append(obstacles, obstacle)
 			}
 		}
 	}
-	return obstacles
+	return obstacles, nil
 }
 
-func DrawMask(win *pixelgl.Window, windowConfig WindowConfig, mapConfig MapConfig) {
+func DrawMask(win Renderer, windowConfig WindowConfig, mapConfig MapConfig) {
 	// top
 	s := imdraw.New(nil)
 	s.Color = colornames.White
@@ -0,0 +1,31 @@
+package zelduh
+
+import (
+	"image/color"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/pixelgl"
+)
+
+// Renderer is a drawing target that can be cleared between frames
+type Renderer interface {
+	pixel.Target
+	Clear(c color.Color)
+	Bounds() pixel.Rect
+}
+
+// InputSource reports the state of buttons
+type InputSource interface {
+	Pressed(button pixelgl.Button) bool
+	JustPressed(button pixelgl.Button) bool
+}
+
+// Window is a Renderer and InputSource that is updated once per frame
+//
+// *pixelgl.Window implements Window, as does HeadlessWindow
+type Window interface {
+	Renderer
+	InputSource
+	Update()
+	Closed() bool
+}
@@ -0,0 +1,190 @@
+package zelduh
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// World is the layout of the rooms, as read from a world file
+//
+// Rooms placed next to each other on the grid are connected both ways.
+// Connections join rooms that are not next to each other, or replace a grid
+// connection. A one-way connection between rooms next to each other on the
+// grid removes the grid connection back.
+type World struct {
+	// Start is the room the game starts in
+	Start       RoomID            `json:"start"`
+	Rooms       []WorldRoom       `json:"rooms"`
+	Connections []WorldConnection `json:"connections"`
+}
+
+// WorldRoom is a room of the world, and its place on the grid
+//
+// X grows to the right and Y grows down, like the rows of a map.
+type WorldRoom struct {
+	ID  RoomID `json:"id"`
+	X   int    `json:"x"`
+	Y   int    `json:"y"`
+	Map string `json:"map"`
+}
+
+// WorldConnection connects a side of one room to another room
+//
+// Unless OneWay is set, the other room's opposite side leads back.
+type WorldConnection struct {
+	From   RoomID `json:"from"`
+	Side   string `json:"side"`
+	To     RoomID `json:"to"`
+	OneWay bool   `json:"oneWay"`
+}
+
+// worldSides are the sides a connection may leave a room by
+var worldSides = map[string]Direction{
+	"top":    DirectionUp,
+	"right":  DirectionRight,
+	"bottom": DirectionDown,
+	"left":   DirectionLeft,
+}
+
+// LoadWorld reads and validates a JSON world file
+//
+// Each room's map must be a TMX file in mapDir.
+func LoadWorld(path, mapDir string) (World, error) {
+	var world World
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return world, err
+	}
+	if err := json.Unmarshal(data, &world); err != nil {
+		return world, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := world.validate(mapDir); err != nil {
+		return world, fmt.Errorf("%s: %w", path, err)
+	}
+	return world, nil
+}
+
+func (w World) validate(mapDir string) error {
+	ids := map[RoomID]bool{}
+	cells := map[[2]int]RoomID{}
+	for _, room := range w.Rooms {
+		if room.ID <= 0 {
+			return fmt.Errorf("room ID must be positive, got %d", room.ID)
+		}
+		if ids[room.ID] {
+			return fmt.Errorf("room %d is declared twice", room.ID)
+		}
+		ids[room.ID] = true
+		if room.X < 0 || room.Y < 0 {
+			return fmt.Errorf("room %d: grid position must not be negative, got %d,%d", room.ID, room.X, room.Y)
+		}
+		cell := [2]int{room.X, room.Y}
+		if other, ok := cells[cell]; ok {
+			return fmt.Errorf("room %d: grid position %d,%d is taken by room %d", room.ID, room.X, room.Y, other)
+		}
+		cells[cell] = room.ID
+		if room.Map == "" {
+			return fmt.Errorf("room %d has no map", room.ID)
+		}
+		if _, err := os.Stat(fmt.Sprintf("%s%s.tmx", mapDir, room.Map)); err != nil {
+			return fmt.Errorf("room %d: %w", room.ID, err)
+		}
+	}
+
+	if !ids[w.Start] {
+		return fmt.Errorf("start room %d is not declared", w.Start)
+	}
+
+	sides := map[RoomID]map[Direction]bool{}
+	connect := func(from RoomID, side Direction) error {
+		if sides[from] == nil {
+			sides[from] = map[Direction]bool{}
+		}
+		if sides[from][side] {
+			return fmt.Errorf("room %d has two connections on side %s", from, side)
+		}
+		sides[from][side] = true
+		return nil
+	}
+	for i, c := range w.Connections {
+		side, ok := worldSides[c.Side]
+		if !ok {
+			return fmt.Errorf("connection %d: unknown side %q", i, c.Side)
+		}
+		if !ids[c.From] {
+			return fmt.Errorf("connection %d: room %d is not declared", i, c.From)
+		}
+		if !ids[c.To] {
+			return fmt.Errorf("connection %d: room %d is not declared", i, c.To)
+		}
+		if c.From == c.To {
+			return fmt.Errorf("connection %d: room %d connects to itself", i, c.From)
+		}
+		if err := connect(c.From, side); err != nil {
+			return fmt.Errorf("connection %d: %w", i, err)
+		}
+		if !c.OneWay {
+			if err := connect(c.To, side.Opposite()); err != nil {
+				return fmt.Errorf("connection %d: %w", i, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Layout returns the room IDs on the grid, by row then column, with 0 where
+// there is no room
+func (w World) Layout() [][]RoomID {
+	layout := [][]RoomID{}
+	for _, room := range w.Rooms {
+		for len(layout) <= room.Y {
+			layout = append(layout, []RoomID{})
+		}
+		for len(layout[room.Y]) <= room.X {
+			layout[room.Y] = append(layout[room.Y], 0)
+		}
+		layout[room.Y][room.X] = room.ID
+	}
+	return layout
+}
+
+// BuildRooms builds the rooms of the world, and connects them
+//
+// entityConfigs are the entities of each room that are not placed in its map.
+func (w World) BuildRooms(entityConfigs map[RoomID][]EntityConfig) Rooms {
+	rooms := Rooms{}
+	for _, room := range w.Rooms {
+		rooms[room.ID] = NewRoom(room.Map, entityConfigs[room.ID]...)
+	}
+
+	BuildMapRoomIDToRoom(w.Layout(), rooms)
+
+	// Remove the grid connections back, before the connections set any side
+	for _, c := range w.Connections {
+		back := worldSides[c.Side].Opposite()
+		if c.OneWay && rooms[c.To].ConnectedRooms().Side(worldBound(back)) == c.From {
+			rooms[c.To].SetConnectedRoom(back, 0)
+		}
+	}
+	for _, c := range w.Connections {
+		side := worldSides[c.Side]
+		rooms[c.From].SetConnectedRoom(side, c.To)
+		if !c.OneWay {
+			rooms[c.To].SetConnectedRoom(side.Opposite(), c.From)
+		}
+	}
+	return rooms
+}
+
+// worldBound returns the bound of a room that leads in the direction; the
+// names of worldSides are those of the bounds
+func worldBound(direction Direction) Bound {
+	for name, side := range worldSides {
+		if side == direction {
+			return Bound(name)
+		}
+	}
+	return ""
+}
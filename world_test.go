@@ -0,0 +1,102 @@
+package zelduh
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWorldValidate(t *testing.T) {
+	rooms := func(extra ...WorldRoom) []WorldRoom {
+		return append([]WorldRoom{
+			{ID: 1, X: 0, Y: 0, Map: "overworldOpen"},
+			{ID: 2, X: 1, Y: 0, Map: "overworldOpen"},
+			{ID: 3, X: 0, Y: 2, Map: "overworldOpen"},
+		}, extra...)
+	}
+
+	tests := []struct {
+		name    string
+		world   World
+		wantErr string
+	}{
+		{"valid", World{Start: 1, Rooms: rooms(), Connections: []WorldConnection{{From: 1, Side: "bottom", To: 3}}}, ""},
+		{"one way", World{Start: 1, Rooms: rooms(), Connections: []WorldConnection{{From: 1, Side: "bottom", To: 3, OneWay: true}, {From: 3, Side: "right", To: 1, OneWay: true}}}, ""},
+		{"duplicate ID", World{Start: 1, Rooms: rooms(WorldRoom{ID: 2, X: 5, Y: 5, Map: "overworldOpen"})}, "room 2 is declared twice"},
+		{"ID not positive", World{Start: 1, Rooms: rooms(WorldRoom{ID: 0, X: 5, Y: 5, Map: "overworldOpen"})}, "room ID must be positive"},
+		{"taken cell", World{Start: 1, Rooms: rooms(WorldRoom{ID: 4, X: 1, Y: 0, Map: "overworldOpen"})}, "room 4: grid position 1,0 is taken by room 2"},
+		{"negative cell", World{Start: 1, Rooms: rooms(WorldRoom{ID: 4, X: -1, Y: 0, Map: "overworldOpen"})}, "room 4: grid position must not be negative"},
+		{"no map", World{Start: 1, Rooms: rooms(WorldRoom{ID: 4, X: 5, Y: 5})}, "room 4 has no map"},
+		{"missing map", World{Start: 1, Rooms: rooms(WorldRoom{ID: 4, X: 5, Y: 5, Map: "missing"})}, "room 4: "},
+		{"undeclared start", World{Start: 9, Rooms: rooms()}, "start room 9 is not declared"},
+		{"unknown side", World{Start: 1, Rooms: rooms(), Connections: []WorldConnection{{From: 1, Side: "up", To: 3}}}, `connection 0: unknown side "up"`},
+		{"undeclared room", World{Start: 1, Rooms: rooms(), Connections: []WorldConnection{{From: 1, Side: "bottom", To: 9}}}, "connection 0: room 9 is not declared"},
+		{"connected to itself", World{Start: 1, Rooms: rooms(), Connections: []WorldConnection{{From: 1, Side: "bottom", To: 1}}}, "room 1 connects to itself"},
+		{"two connections on one side", World{Start: 1, Rooms: rooms(), Connections: []WorldConnection{
+			{From: 1, Side: "bottom", To: 3},
+			{From: 1, Side: "bottom", To: 2, OneWay: true},
+		}}, "connection 1: room 1 has two connections on side"},
+		{"two connections on the side back", World{Start: 1, Rooms: rooms(), Connections: []WorldConnection{
+			{From: 1, Side: "bottom", To: 3},
+			{From: 2, Side: "bottom", To: 3},
+		}}, "connection 1: room 3 has two connections on side"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.world.validate("assets/tilemaps/")
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validate = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validate = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWorldBuildRooms(t *testing.T) {
+	world := World{
+		Start: 1,
+		Rooms: []WorldRoom{
+			{ID: 1, X: 0, Y: 0, Map: "overworldOpen"},
+			{ID: 2, X: 1, Y: 0, Map: "overworldOpen"},
+			{ID: 3, X: 0, Y: 1, Map: "overworldOpen"},
+			{ID: 4, X: 3, Y: 3, Map: "overworldOpen"},
+		},
+		Connections: []WorldConnection{
+			// One way between grid neighbours: 2 no longer leads back to 1
+			{From: 1, Side: "right", To: 2, OneWay: true},
+			// Replaces the grid connection from 3 up to 1
+			{From: 3, Side: "top", To: 4},
+		},
+	}
+	if err := world.validate("assets/tilemaps/"); err != nil {
+		t.Fatal(err)
+	}
+	rooms := world.BuildRooms(nil)
+
+	tests := []struct {
+		room RoomID
+		want ConnectedRooms
+	}{
+		{1, ConnectedRooms{Right: 2, Bottom: 3}},
+		{2, ConnectedRooms{}},
+		{3, ConnectedRooms{Top: 4}},
+		{4, ConnectedRooms{Bottom: 3}},
+	}
+	for _, tt := range tests {
+		if got := *rooms[tt.room].ConnectedRooms(); got != tt.want {
+			t.Errorf("room %d connections = %+v, want %+v", tt.room, got, tt.want)
+		}
+	}
+}
+
+func TestWorldBound(t *testing.T) {
+	for name, direction := range worldSides {
+		if got := worldBound(direction); got != Bound(name) {
+			t.Errorf("worldBound(%s) = %q, want %q", direction, got, name)
+		}
+	}
+}